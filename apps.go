@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
 )
 
 const (
@@ -37,12 +36,14 @@ type apps struct {
 }
 
 func (p apps) CreateApp(ctx context.Context, input *AppInput, opts ...Option) (*AppOutput, error) {
+	ctx = withOperation(ctx, "Apps.CreateApp")
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPost(ctx, pathApps, bytes.NewReader(payload), nil)
+	resp, err := p.client.doPost(ctx, pathApps, bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -54,12 +55,17 @@ func (p apps) CreateApp(ctx context.Context, input *AppInput, opts ...Option) (*
 	}
 
 	return &AppOutput{
-		Data: &app,
+		Data:     &app,
+		DryRun:   resp.DryRun,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 func (p apps) GetApp(ctx context.Context, id int64, opts ...Option) (*AppOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathApp, id), nil)
+	ctx = withOperation(ctx, "Apps.GetApp", id)
+
+	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathApp, id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -70,17 +76,21 @@ func (p apps) GetApp(ctx context.Context, id int64, opts ...Option) (*AppOutput,
 	}
 
 	return &AppOutput{
-		Data: &app,
+		Data:     &app,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 func (p apps) UpdateApp(ctx context.Context, id int64, input *AppInput, opts ...Option) (*AppOutput, error) {
+	ctx = withOperation(ctx, "Apps.UpdateApp", id)
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathApp, id), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathApp, id), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -91,12 +101,17 @@ func (p apps) UpdateApp(ctx context.Context, id int64, input *AppInput, opts ...
 	}
 
 	return &AppOutput{
-		Data: &app,
+		Data:     &app,
+		DryRun:   resp.DryRun,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 func (p apps) DeleteApp(ctx context.Context, id int64, opts ...Option) (*AppOutput, error) {
-	resp, err := p.client.doDelete(ctx, fmt.Sprintf(pathApp, id), nil, nil)
+	ctx = withOperation(ctx, "Apps.DeleteApp", id)
+
+	resp, err := p.client.doDelete(ctx, fmt.Sprintf(pathApp, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -107,12 +122,17 @@ func (p apps) DeleteApp(ctx context.Context, id int64, opts ...Option) (*AppOutp
 	}
 
 	return &AppOutput{
-		Data: &app,
+		Data:     &app,
+		DryRun:   resp.DryRun,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 func (p apps) ProvisionApp(ctx context.Context, id int64, opts ...Option) (*StatusOutput, error) {
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathAppProvision, id), nil, nil)
+	ctx = withOperation(ctx, "Apps.ProvisionApp", id)
+
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathAppProvision, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -123,29 +143,32 @@ func (p apps) ProvisionApp(ctx context.Context, id int64, opts ...Option) (*Stat
 	}
 
 	return &StatusOutput{
-		Data: &status,
+		Data:     &status,
+		DryRun:   resp.DryRun,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 // ListApps lists apps
 func (p apps) ListApps(ctx context.Context, opts ...Option) (*ListAppsOutput, error) {
-	resp, err := p.client.doGet(ctx, pathApps, url.Values{"p": []string{"-2"}}, opts...)
-	if err != nil {
-		return nil, err
-	}
+	ctx = withOperation(ctx, "Apps.ListApps")
 
-	var ars []App
-
-	if err := resp.Unmarshal(&ars); err != nil {
+	ars, resp, err := listItems[App](ctx, p.client, pathApps, unpaginated(), opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListAppsOutput{
-		Data: ars,
+		Data:     ars,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 func (p apps) ListARs(ctx context.Context, id int64, opts ...Option) (*ListARsOutput, error) {
+	ctx = withOperation(ctx, "Apps.ListARs", id)
+
 	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathApp, id), nil, opts...)
 	if err != nil {
 		return nil, err
@@ -160,11 +183,15 @@ func (p apps) ListARs(ctx context.Context, id int64, opts ...Option) (*ListARsOu
 	}
 
 	return &ListARsOutput{
-		Data: ars.AccessRequests,
+		Data:     ars.AccessRequests,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 func (p apps) GetAR(ctx context.Context, appID, arID int64, opts ...Option) (*AROutput, error) {
+	ctx = withOperation(ctx, "Apps.GetAR", appID, arID)
+
 	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathAppAR, appID, arID), nil, opts...)
 	if err != nil {
 		return nil, err
@@ -177,18 +204,23 @@ func (p apps) GetAR(ctx context.Context, appID, arID int64, opts ...Option) (*AR
 	}
 
 	return &AROutput{
-		Data: &ar,
+		Data:     &ar,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 type ListAppsOutput struct {
 	Response *http.Response
 	Data     []App
+	Meta     Meta
 }
 
 type AppOutput struct {
 	Response *http.Response
 	Data     *App
+	DryRun   bool
+	Meta     Meta
 }
 
 type App struct {
@@ -32,6 +32,8 @@ type ars struct {
 
 // GetAccessRequest ...
 func (p ars) GetAR(ctx context.Context, id int64, opts ...Option) (*AROutput, error) {
+	ctx = withOperation(ctx, "ARs.GetAR", id)
+
 	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathAccessRequest, id), nil, opts...)
 	if err != nil {
 		return nil, err
@@ -43,35 +45,39 @@ func (p ars) GetAR(ctx context.Context, id int64, opts ...Option) (*AROutput, er
 	}
 
 	return &AROutput{
-		Data: &ar,
+		Data:     &ar,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 // ListAccessRequests ...
 func (p ars) ListARs(ctx context.Context, opts ...Option) (*ListARsOutput, error) {
-	resp, err := p.client.doGet(ctx, pathAccessRequests, nil, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	var ars []ARDetails
+	ctx = withOperation(ctx, "ARs.ListARs")
 
-	if err := resp.Unmarshal(&ars); err != nil {
+	ars, resp, err := listItems[ARDetails](ctx, p.client, pathAccessRequests, nil, opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListARsOutput{
-		Data: ars,
+		Data:     ars,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 type StatusOutput struct {
 	Data     *Status
 	Response *http.Response
+	DryRun   bool
+	Meta     Meta
 }
 
 // UpdateAccessRequest ...
 func (p ars) ApproveAR(ctx context.Context, id int64, opts ...Option) (*StatusOutput, error) {
+	ctx = withOperation(ctx, "ARs.ApproveAR", id)
+
 	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathAccessRequestApprove, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
@@ -84,12 +90,17 @@ func (p ars) ApproveAR(ctx context.Context, id int64, opts ...Option) (*StatusOu
 	}
 
 	return &StatusOutput{
-		Data: &ar,
+		Data:     &ar,
+		DryRun:   resp.DryRun,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 // UpdateAccessRequest ...
 func (p ars) RejectAR(ctx context.Context, id int64, opts ...Option) (*StatusOutput, error) {
+	ctx = withOperation(ctx, "ARs.RejectAR", id)
+
 	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathAccessRequestReject, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
@@ -102,12 +113,17 @@ func (p ars) RejectAR(ctx context.Context, id int64, opts ...Option) (*StatusOut
 	}
 
 	return &StatusOutput{
-		Data: &ar,
+		Data:     &ar,
+		DryRun:   resp.DryRun,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 // UpdateAccessRequest ...
 func (p ars) DeleteAR(ctx context.Context, id int64, opts ...Option) (*StatusOutput, error) {
+	ctx = withOperation(ctx, "ARs.DeleteAR", id)
+
 	resp, err := p.client.doDelete(ctx, fmt.Sprintf(pathAccessRequest, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
@@ -119,7 +135,10 @@ func (p ars) DeleteAR(ctx context.Context, id int64, opts ...Option) (*StatusOut
 	}
 
 	return &StatusOutput{
-		Data: &ar,
+		Data:     &ar,
+		DryRun:   resp.DryRun,
+		Response: resp.Response,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
@@ -207,9 +226,11 @@ type Credentials struct {
 type ListARsOutput struct {
 	Data     []ARDetails
 	Response *http.Response
+	Meta     Meta
 }
 
 type AROutput struct {
 	Data     *ARDetails
 	Response *http.Response
+	Meta     Meta
 }
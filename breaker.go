@@ -0,0 +1,323 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerFailureRatio = 0.5
+	defaultBreakerMinRequests  = 10
+	defaultBreakerWindow       = 30 * time.Second
+	defaultBreakerOpenTimeout  = 30 * time.Second
+	defaultBreakerProbes       = 1
+)
+
+// ErrCircuitOpen is matched by errors.Is for requests rejected because the
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned when a request is rejected without being
+// sent because the breaker for its base URL is open.
+type CircuitOpenError struct {
+	BaseURL string
+	// Until is when the breaker lets the next probe request through.
+	Until time.Time
+}
+
+func (e CircuitOpenError) Error() string {
+	return fmt.Sprintf("%v: %v until %v", ErrCircuitOpen, e.BaseURL, e.Until.Format(time.RFC3339))
+}
+
+func (e CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures WithCircuitBreaker. Zero values are
+// replaced by defaults.
+type CircuitBreakerConfig struct {
+	// FailureRatio is the ratio of failed requests within Window that opens
+	// the breaker. Network errors and 5xx responses count as failures;
+	// requests cancelled by their context are not counted.
+	FailureRatio float64
+	// MinRequests is the number of requests needed within Window before the
+	// failure ratio is evaluated.
+	MinRequests int
+	// Window is the period over which requests are counted.
+	Window time.Duration
+	// OpenTimeout is how long the breaker stays open before letting probe
+	// requests through.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of probe requests allowed at once while
+	// half-open.
+	HalfOpenProbes int
+	// OnStateChange, when set, is called after every state change.
+	OnStateChange func(baseURL string, from, to CircuitState)
+}
+
+func (cfg CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if cfg.FailureRatio <= 0 || cfg.FailureRatio > 1 {
+		cfg.FailureRatio = defaultBreakerFailureRatio
+	}
+
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = defaultBreakerMinRequests
+	}
+
+	if cfg.Window <= 0 {
+		cfg.Window = defaultBreakerWindow
+	}
+
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultBreakerOpenTimeout
+	}
+
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = defaultBreakerProbes
+	}
+
+	return cfg
+}
+
+// WithCircuitBreaker enables a circuit breaker per base URL. Copies of the
+// client share the breakers, so every call against the same portal sees the
+// same state.
+func WithCircuitBreaker(cfg CircuitBreakerConfig) Option {
+	return func(c *Client) {
+		c.breakers = &breakerRegistry{
+			cfg:      cfg.withDefaults(),
+			breakers: map[string]*circuitBreaker{},
+		}
+	}
+}
+
+type breakerRegistry struct {
+	cfg      CircuitBreakerConfig
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func (r *breakerRegistry) get(baseURL string) *circuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[baseURL]
+	if !ok {
+		b = &circuitBreaker{
+			cfg:         r.cfg,
+			baseURL:     baseURL,
+			windowStart: time.Now(),
+		}
+		r.breakers[baseURL] = b
+	}
+
+	return b
+}
+
+type circuitBreaker struct {
+	cfg     CircuitBreakerConfig
+	baseURL string
+
+	mu          sync.Mutex
+	state       CircuitState
+	requests    int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	probes      int
+	// generation counts state changes, so that requests finishing after
+	// the state they were allowed in has ended are ignored.
+	generation int
+	changes    [][2]CircuitState
+}
+
+// breakerTicket records the state a request was allowed in.
+type breakerTicket struct {
+	generation int
+	probe      bool
+}
+
+// allow reports whether a request may be sent. Every allowed request must be
+// followed by a call to done or cancel with the returned ticket.
+func (b *circuitBreaker) allow() (breakerTicket, error) {
+	b.mu.Lock()
+
+	now := time.Now()
+
+	var err error
+
+	if b.state == CircuitOpen {
+		if until := b.openedAt.Add(b.cfg.OpenTimeout); now.Before(until) {
+			err = CircuitOpenError{BaseURL: b.baseURL, Until: until}
+		} else {
+			b.setState(CircuitHalfOpen)
+		}
+	}
+
+	ticket := breakerTicket{generation: b.generation}
+
+	if err == nil && b.state == CircuitHalfOpen {
+		if b.probes >= b.cfg.HalfOpenProbes {
+			err = CircuitOpenError{BaseURL: b.baseURL, Until: now}
+		} else {
+			b.probes++
+			ticket.probe = true
+		}
+	}
+
+	b.unlock()
+
+	return ticket, err
+}
+
+// done records the outcome of a request. Only probes change a half-open
+// breaker, and requests allowed before the last state change are ignored.
+func (b *circuitBreaker) done(ticket breakerTicket, failed bool) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	if ticket.generation != b.generation {
+		return
+	}
+
+	now := time.Now()
+
+	switch b.state {
+	case CircuitHalfOpen:
+		if !ticket.probe {
+			return
+		}
+
+		b.probes--
+
+		if failed {
+			b.open(now)
+			return
+		}
+
+		b.setState(CircuitClosed)
+		b.resetWindow(now)
+	case CircuitClosed:
+		if now.Sub(b.windowStart) > b.cfg.Window {
+			b.resetWindow(now)
+		}
+
+		b.requests++
+		if failed {
+			b.failures++
+		}
+
+		if b.requests >= b.cfg.MinRequests && float64(b.failures)/float64(b.requests) >= b.cfg.FailureRatio {
+			b.open(now)
+		}
+	case CircuitOpen:
+	}
+}
+
+// cancel releases a request allowed by allow that was never sent.
+func (b *circuitBreaker) cancel(ticket breakerTicket) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	if ticket.probe && ticket.generation == b.generation {
+		b.probes--
+	}
+}
+
+func (b *circuitBreaker) open(now time.Time) {
+	b.openedAt = now
+	b.probes = 0
+	b.setState(CircuitOpen)
+}
+
+func (b *circuitBreaker) resetWindow(now time.Time) {
+	b.requests = 0
+	b.failures = 0
+	b.windowStart = now
+}
+
+// setState must be called with b.mu held. The change is reported once the
+// lock is released, so the callback may safely use the client.
+func (b *circuitBreaker) setState(to CircuitState) {
+	from := b.state
+	if from == to {
+		return
+	}
+
+	b.state = to
+	b.generation++
+	b.changes = append(b.changes, [2]CircuitState{from, to})
+}
+
+// unlock releases b.mu and reports the state changes made while it was held.
+func (b *circuitBreaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+
+	if b.cfg.OnStateChange == nil {
+		return
+	}
+
+	for _, change := range changes {
+		b.cfg.OnStateChange(b.baseURL, change[0], change[1])
+	}
+}
+
+func (b *circuitBreaker) currentState() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// breakerFailure reports whether the outcome of an attempt counts against
+// the breaker. Network errors and 5xx responses do.
+func breakerFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// breaker returns the breaker for endpoint, or for the base URL when no
+// endpoint is given.
+func (c Client) breaker(endpoint string) *circuitBreaker {
+	if c.breakers == nil {
+		return nil
+	}
+
+	if endpoint == "" {
+		endpoint = c.baseURL
+	}
+
+	return c.breakers.get(endpoint)
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var (
+		healthy int32
+		calls   int32
+	)
+
+	srv.mux.HandleFunc("/portal-api/plans/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_, err := w.Write([]byte(`{"ID":1}`))
+		assert.NoError(t, err)
+	})
+
+	var (
+		mu          sync.Mutex
+		transitions []string
+	)
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxRetries(0),
+		WithCircuitBreaker(CircuitBreakerConfig{
+			FailureRatio: 0.5,
+			MinRequests:  4,
+			OpenTimeout:  50 * time.Millisecond,
+			OnStateChange: func(baseURL string, from, to CircuitState) {
+				assert.Equal(t, srv.srv.URL, baseURL)
+
+				mu.Lock()
+				transitions = append(transitions, from.String()+"->"+to.String())
+				mu.Unlock()
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err := client.Plans().GetPlan(context.Background(), 1)
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrCircuitOpen))
+	}
+
+	_, err = client.Plans().GetPlan(context.Background(), 1, WithToken("OTHER"))
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	var openErr CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, srv.srv.URL, openErr.BaseURL)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+
+	_, err = client.Plans().GetPlan(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, CircuitClosed, client.breaker("").currentState())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, transitions)
+}
+
+func TestCircuitBreaker_HalfOpenFailureReopens(t *testing.T) {
+	b := (&breakerRegistry{
+		cfg:      CircuitBreakerConfig{MinRequests: 1, OpenTimeout: time.Millisecond}.withDefaults(),
+		breakers: map[string]*circuitBreaker{},
+	}).get("http://portal")
+
+	ticket, err := b.allow()
+	require.NoError(t, err)
+	b.done(ticket, true)
+	assert.Equal(t, CircuitOpen, b.currentState())
+
+	time.Sleep(2 * time.Millisecond)
+
+	probe, err := b.allow()
+	require.NoError(t, err)
+	assert.Equal(t, CircuitHalfOpen, b.currentState())
+
+	_, err = b.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	b.done(probe, true)
+	assert.Equal(t, CircuitOpen, b.currentState())
+}
+
+func TestCircuitBreaker_OnlyProbesChangeHalfOpen(t *testing.T) {
+	b := (&breakerRegistry{
+		cfg:      CircuitBreakerConfig{MinRequests: 1, OpenTimeout: time.Millisecond}.withDefaults(),
+		breakers: map[string]*circuitBreaker{},
+	}).get("http://portal")
+
+	slow, err := b.allow()
+	require.NoError(t, err)
+
+	failing, err := b.allow()
+	require.NoError(t, err)
+	b.done(failing, true)
+	assert.Equal(t, CircuitOpen, b.currentState())
+
+	time.Sleep(2 * time.Millisecond)
+
+	probe, err := b.allow()
+	require.NoError(t, err)
+	assert.Equal(t, CircuitHalfOpen, b.currentState())
+
+	b.done(slow, false)
+	assert.Equal(t, CircuitHalfOpen, b.currentState(), "a request allowed before the breaker opened is not a probe")
+	assert.Equal(t, 1, b.probes)
+
+	b.cancel(slow)
+	assert.Equal(t, 1, b.probes)
+
+	b.done(probe, false)
+	assert.Equal(t, CircuitClosed, b.currentState())
+	assert.Equal(t, 0, b.probes)
+}
+
+func TestCircuitBreaker_CancelledProbe(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var healthy int32
+
+	srv.mux.HandleFunc("/portal-api/plans/1", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	var (
+		mu          sync.Mutex
+		transitions []string
+	)
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxRetries(0),
+		WithCircuitBreaker(CircuitBreakerConfig{
+			MinRequests: 1,
+			OpenTimeout: 10 * time.Millisecond,
+			OnStateChange: func(baseURL string, from, to CircuitState) {
+				mu.Lock()
+				transitions = append(transitions, from.String()+"->"+to.String())
+				mu.Unlock()
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Plans().GetPlan(context.Background(), 1)
+	require.Error(t, err)
+	assert.Equal(t, CircuitOpen, client.breaker("").currentState())
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = client.Plans().GetPlan(ctx, 1)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	b := client.breaker("")
+	assert.Equal(t, CircuitHalfOpen, b.currentState(), "a cancelled probe does not close the breaker")
+
+	b.mu.Lock()
+	assert.Zero(t, b.probes)
+	b.mu.Unlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []string{"closed->open", "open->half-open"}, transitions)
+}
@@ -0,0 +1,362 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	headerETag            = "ETag"
+	headerLastModified    = "Last-Modified"
+	headerIfNoneMatch     = "If-None-Match"
+	headerIfModifiedSince = "If-Modified-Since"
+
+	defaultCacheEntries = 256
+	cacheFileExt        = ".json"
+)
+
+// CacheEntry is a cached GET response.
+type CacheEntry struct {
+	// Key is the cache key the entry was stored under.
+	Key        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Cache stores GET responses that carry an ETag or Last-Modified validator.
+// Keys are request URLs without the query, followed by "?" and the encoded
+// query when there is one. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(entry *CacheEntry)
+	Delete(key string)
+	// DeletePrefix removes every entry whose key starts with prefix.
+	DeletePrefix(prefix string)
+}
+
+// WithCache enables conditional GETs. Responses with an ETag or
+// Last-Modified header are stored in cache, later GETs for the same URL send
+// If-None-Match and If-Modified-Since, and a 304 is answered with the cached
+// body. POST, PUT, PATCH and DELETE requests invalidate the entries for their
+// path, the paths below it and the collection it belongs to.
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+func cacheKey(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	if req.URL.RawQuery == "" {
+		return u.String()
+	}
+
+	return u.String() + "?" + req.URL.RawQuery
+}
+
+// prepareConditional adds the validators of the cached entry for req, if
+// any, and returns that entry.
+func (c Client) prepareConditional(req *http.Request) *CacheEntry {
+	if c.cache == nil || req.Method != http.MethodGet {
+		return nil
+	}
+
+	entry, ok := c.cache.Get(cacheKey(req))
+	if !ok {
+		return nil
+	}
+
+	if etag := entry.Header.Get(headerETag); etag != "" {
+		req.Header.Set(headerIfNoneMatch, etag)
+	}
+
+	if lastModified := entry.Header.Get(headerLastModified); lastModified != "" {
+		req.Header.Set(headerIfModifiedSince, lastModified)
+	}
+
+	return entry
+}
+
+// cacheResponse stores a cacheable response, or turns a 304 into the cached
+// response it validated.
+func (c Client) cacheResponse(req *http.Request, resp *APIResponse, entry *CacheEntry) *APIResponse {
+	if c.cache == nil || req.Method != http.MethodGet {
+		return resp
+	}
+
+	if resp.Response.StatusCode == http.StatusNotModified && entry != nil {
+		return &APIResponse{
+			Body:      entry.Body,
+			RequestID: resp.Response.Header.Get(headerRequestID),
+			Response: &http.Response{
+				Status:     http.StatusText(entry.StatusCode),
+				StatusCode: entry.StatusCode,
+				Header:     entry.Header.Clone(),
+				Request:    req,
+			},
+		}
+	}
+
+	if resp.Response.StatusCode != http.StatusOK {
+		return resp
+	}
+
+	if resp.Response.Header.Get(headerETag) == "" && resp.Response.Header.Get(headerLastModified) == "" {
+		return resp
+	}
+
+	c.cache.Set(&CacheEntry{
+		Key:        cacheKey(req),
+		StatusCode: resp.Response.StatusCode,
+		Header:     resp.Response.Header.Clone(),
+		Body:       resp.Body,
+	})
+
+	return resp
+}
+
+// invalidateCache drops the entries a mutating request may have made stale:
+// the resource itself, anything below it and its parent collection.
+func (c Client) invalidateCache(req *http.Request) {
+	if c.cache == nil {
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return
+	}
+
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	resource := u.String()
+	c.cache.DeletePrefix(resource + "?")
+	c.cache.DeletePrefix(resource + "/")
+	c.cache.Delete(resource)
+
+	u.Path = path.Dir(u.Path)
+	u.RawPath = ""
+
+	collection := u.String()
+	c.cache.DeletePrefix(collection + "?")
+	c.cache.Delete(collection)
+}
+
+// MemoryCache is an in-memory Cache that evicts the least recently used
+// entry once it holds its maximum number of entries.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+// NewMemoryCache returns a MemoryCache holding up to maxEntries entries, or
+// 256 when maxEntries is not positive.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+	}
+}
+
+func (m *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	m.order.MoveToFront(elem)
+
+	entry, _ := elem.Value.(*CacheEntry)
+
+	return entry, true
+}
+
+func (m *MemoryCache) Set(entry *CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[entry.Key]; ok {
+		elem.Value = entry
+		m.order.MoveToFront(elem)
+
+		return
+	}
+
+	m.entries[entry.Key] = m.order.PushFront(entry)
+
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.remove(oldest)
+	}
+}
+
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+}
+
+func (m *MemoryCache) DeletePrefix(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, elem := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			m.remove(elem)
+		}
+	}
+}
+
+// Len returns the number of cached entries.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+func (m *MemoryCache) remove(elem *list.Element) {
+	m.order.Remove(elem)
+
+	if entry, ok := elem.Value.(*CacheEntry); ok {
+		delete(m.entries, entry.Key)
+	}
+}
+
+// DiskCache is a Cache that keeps one JSON file per entry in a directory, so
+// cached responses survive restarts.
+type DiskCache struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing entries in dir, creating it when
+// needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	return &DiskCache{dir: dir}, nil
+}
+
+func (d *DiskCache) Get(key string) (*CacheEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, err := d.read(d.file(key))
+	if err != nil || entry.Key != key {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (d *DiskCache) Set(entry *CacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Write to a temporary file first so readers never see a partial entry.
+	tmp, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), d.file(entry.Key))
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (d *DiskCache) Delete(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_ = os.Remove(d.file(key))
+}
+
+func (d *DiskCache) DeletePrefix(prefix string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(d.dir, "*"+cacheFileExt))
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		entry, err := d.read(file)
+		if err != nil || strings.HasPrefix(entry.Key, prefix) {
+			_ = os.Remove(file)
+		}
+	}
+}
+
+func (d *DiskCache) file(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+cacheFileExt)
+}
+
+func (d *DiskCache) read(file string) (*CacheEntry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	if entry.Key == "" {
+		return nil, errors.New("cache entry has no key")
+	}
+
+	return &entry, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCachingServer(t *testing.T, version *int32, full *int32) *server {
+	srv := NewServer(t)
+
+	srv.mux.HandleFunc("/portal-api/products", func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, atomic.LoadInt32(version))
+		w.Header().Set(headerETag, etag)
+
+		if r.Header.Get(headerIfNoneMatch) == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		atomic.AddInt32(full, 1)
+
+		_, err := fmt.Fprintf(w, `[{"ID":%d,"Name":"Product"}]`, atomic.LoadInt32(version))
+		assert.NoError(t, err)
+	})
+
+	srv.mux.HandleFunc("/portal-api/products/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(version, 1)
+
+		_, err := w.Write([]byte(`{"ID":1,"Name":"Product"}`))
+		assert.NoError(t, err)
+	})
+
+	return srv
+}
+
+func TestCache_ConditionalGet(t *testing.T) {
+	var version, full int32 = 1, 0
+
+	srv := newCachingServer(t, &version, &full)
+	defer srv.Close()
+
+	cache := NewMemoryCache(0)
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithCache(cache),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		out, err := client.Products().ListProducts(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, out.Data, 1)
+		assert.Equal(t, 1, out.Data[0].ID)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&full))
+	assert.Equal(t, 1, cache.Len())
+
+	_, err = client.Products().UpdateProduct(context.Background(), 1, &UpdateProductInput{DisplayName: "Product"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, cache.Len(), "update invalidates the collection")
+
+	out, err := client.Products().ListProducts(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, out.Data[0].ID)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&full))
+}
+
+func TestCache_Disk(t *testing.T) {
+	var version, full int32 = 1, 0
+
+	srv := newCachingServer(t, &version, &full)
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	for i := 0; i < 2; i++ {
+		// A new cache on the same directory sees the entries of the previous
+		// one, as after a restart.
+		cache, err := NewDiskCache(dir)
+		require.NoError(t, err)
+
+		client, err := New(
+			WithBaseURL(srv.srv.URL),
+			WithToken("TOKEN"),
+			WithCache(cache),
+		)
+		require.NoError(t, err)
+
+		out, err := client.Products().ListProducts(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, out.Data[0].ID)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&full))
+
+	cache, err := NewDiskCache(dir)
+	require.NoError(t, err)
+
+	cache.DeletePrefix(srv.srv.URL + "/portal-api/products")
+
+	_, ok := cache.Get(srv.srv.URL + "/portal-api/products")
+	assert.False(t, ok)
+}
+
+func TestMemoryCache_Eviction(t *testing.T) {
+	cache := NewMemoryCache(2)
+
+	cache.Set(&CacheEntry{Key: "a"})
+	cache.Set(&CacheEntry{Key: "b"})
+
+	_, ok := cache.Get("a")
+	require.True(t, ok)
+
+	cache.Set(&CacheEntry{Key: "c"})
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok, "least recently used entry is evicted")
+
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestCache_InvalidateScope(t *testing.T) {
+	cache := NewMemoryCache(0)
+	client := Client{cache: cache}
+
+	for _, key := range []string{
+		"http://portal/portal-api/products",
+		"http://portal/portal-api/products?page=2",
+		"http://portal/portal-api/products/1",
+		"http://portal/portal-api/products/1/docs",
+		"http://portal/portal-api/products/10",
+		"http://portal/portal-api/catalogues",
+	} {
+		cache.Set(&CacheEntry{Key: key})
+	}
+
+	req, err := http.NewRequest(http.MethodPut, "http://portal/portal-api/products/1", http.NoBody)
+	require.NoError(t, err)
+
+	client.invalidateCache(req)
+
+	for key, want := range map[string]bool{
+		"http://portal/portal-api/products":        false,
+		"http://portal/portal-api/products?page=2": false,
+		"http://portal/portal-api/products/1":      false,
+		"http://portal/portal-api/products/1/docs": false,
+		"http://portal/portal-api/products/10":     true,
+		"http://portal/portal-api/catalogues":      true,
+	} {
+		_, ok := cache.Get(key)
+		assert.Equal(t, want, ok, key)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// pathPing is the endpoint Ping GETs. Every portal version serves it and it
+// requires a valid admin token.
+const pathPing = pathProviders
+
+// ErrUnsupported is matched by errors.Is for operations rejected because the
+// portal is too old to support them, or its version is unknown.
+var ErrUnsupported = errors.New("not supported by the portal")
+
+// UnsupportedError is returned, without sending a request, by operations
+// that need a capability the portal's version does not have.
+type UnsupportedError struct {
+	Operation  string
+	Capability Capability
+	// Version is the portal's version, empty when it is unknown, and
+	// MinVersion the first version with the capability.
+	Version    string
+	MinVersion string
+}
+
+func (e UnsupportedError) Error() string {
+	version := e.Version
+	if version == "" {
+		version = "unknown version"
+	}
+
+	return fmt.Sprintf("%v: %v %v: needs portal %v, got %v", e.Operation, e.Capability, ErrUnsupported, e.MinVersion, version)
+}
+
+func (e UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// Capability is a portal feature that only some versions have.
+//
+// The portal API has no documented way to report its version or features,
+// so the client neither detects the version on its own nor ships minimum
+// versions. Capability checks are opt-in: the caller supplies the version,
+// with WithPortalVersion or WithPortalVersionHeader, and the minimum
+// versions, with WithCapabilities. Only capabilities that the client has
+// operations for are defined; content blocks and custom attributes will be
+// added with their operations.
+type Capability string
+
+// CapabilityThemes is the themes API, used by Themes.UploadTheme.
+const CapabilityThemes Capability = "themes"
+
+// WithCapabilities sets the first portal version that has each capability.
+// Operations needing a capability listed here fail with an
+// UnsupportedError, without sending a request, when the portal's version
+// is older or unknown. Capabilities that are not listed are assumed to be
+// supported, and no minimum versions are set by default, so without this
+// option ErrUnsupported is never returned.
+func WithCapabilities(minVersions map[Capability]string) Option {
+	return func(c *Client) {
+		versions := make(map[Capability]string, len(minVersions))
+
+		for capability, v := range minVersions {
+			if _, ok := parseVersion(v); !ok {
+				c.optionErr = fmt.Errorf("invalid minimum version %q for capability %v", v, capability)
+				return
+			}
+
+			versions[capability] = v
+		}
+
+		c.minVersions = versions
+	}
+}
+
+// WithPortalVersion sets the portal's version instead of detecting it.
+func WithPortalVersion(version string) Option {
+	return func(c *Client) {
+		c.portalVersion = version
+	}
+}
+
+// WithPortalVersionHeader makes the client detect the portal's version
+// from the named response header, for deployments where the portal or a
+// proxy in front of it reports the version.
+func WithPortalVersionHeader(name string) Option {
+	return func(c *Client) {
+		c.versionHeader = name
+	}
+}
+
+type PingOutput struct {
+	// Version is the portal's version, empty when it is unknown.
+	Version      string
+	Capabilities []Capability
+	Meta         Meta
+}
+
+// Ping checks that the portal is reachable and accepts the client's token.
+// It does not detect the portal's version by itself: Version is the one set
+// with WithPortalVersion or, when WithPortalVersionHeader is set, the one
+// read from that header, which then enables capability checks for the
+// client and its copies. Otherwise Version is empty.
+func (c *Client) Ping(ctx context.Context, opts ...Option) (*PingOutput, error) {
+	ctx = withOperation(ctx, "Client.Ping")
+
+	resp, err := c.doGet(ctx, pathPing, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := c.copy(opts...)
+
+	return &PingOutput{
+		Version:      client.PortalVersion(),
+		Capabilities: client.Capabilities(),
+		Meta:         resp.Meta(),
+	}, nil
+}
+
+// PortalVersion returns the version set with WithPortalVersion or else the
+// last one detected. It is empty until the version is known.
+func (c Client) PortalVersion() string {
+	if c.portalVersion != "" {
+		return c.portalVersion
+	}
+
+	if c.server == nil {
+		return ""
+	}
+
+	return c.server.get()
+}
+
+// Capabilities returns the capabilities configured with WithCapabilities
+// that the portal's version has, sorted by name. It returns nil when the
+// version is unknown.
+func (c Client) Capabilities() []Capability {
+	if _, ok := parseVersion(c.PortalVersion()); !ok {
+		return nil
+	}
+
+	caps := []Capability{}
+
+	for capability := range c.minVersions {
+		if c.Supports(capability) {
+			caps = append(caps, capability)
+		}
+	}
+
+	sort.Slice(caps, func(i, j int) bool { return caps[i] < caps[j] })
+
+	return caps
+}
+
+// Supports reports whether the portal has capability.
+func (c Client) Supports(capability Capability) bool {
+	return c.checkCapability(context.Background(), capability) == nil
+}
+
+// checkCapability returns an UnsupportedError when capability has a
+// minimum version and the portal's version is older or unknown.
+func (c Client) checkCapability(ctx context.Context, capability Capability, opts ...Option) error {
+	client := c.copy(opts...)
+
+	minVersion, ok := client.minVersions[capability]
+	if !ok {
+		return nil
+	}
+
+	version := client.PortalVersion()
+
+	if current, ok := parseVersion(version); ok {
+		if required, _ := parseVersion(minVersion); !current.less(required) {
+			return nil
+		}
+	} else {
+		version = ""
+	}
+
+	return UnsupportedError{
+		Operation:  operationFromContext(ctx).name,
+		Capability: capability,
+		Version:    version,
+		MinVersion: minVersion,
+	}
+}
+
+type serverInfo struct {
+	mu      sync.Mutex
+	version string
+}
+
+func (s *serverInfo) get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.version
+}
+
+// observeVersion records the version reported in resp.
+func (c Client) observeVersion(resp *http.Response) {
+	if c.server == nil || c.versionHeader == "" || resp == nil {
+		return
+	}
+
+	version := strings.TrimSpace(resp.Header.Get(c.versionHeader))
+	if version == "" {
+		return
+	}
+
+	c.server.mu.Lock()
+	c.server.version = version
+	c.server.mu.Unlock()
+}
+
+type version [3]int
+
+func (v version) less(other version) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+
+	return false
+}
+
+// parseVersion parses versions such as "1.8", "v1.8.3" and "1.8.3-rc1".
+// Pre-release and build suffixes are ignored.
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+ "); i >= 0 {
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if s == "" || len(parts) > 3 {
+		return version{}, false
+	}
+
+	var v version
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+
+		v[i] = n
+	}
+
+	return v, true
+}
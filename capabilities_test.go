@@ -0,0 +1,140 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var uploads int32
+
+	srv.mux.HandleFunc("/portal-api/providers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerAuthorization) != "TOKEN" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			_, err := w.Write([]byte(`{"status":"error","errors":["unauthorized"]}`))
+			assert.NoError(t, err)
+
+			return
+		}
+
+		w.Header().Set("X-Portal-Version", "1.2.1")
+
+		_, err := w.Write([]byte(`[]`))
+		assert.NoError(t, err)
+	})
+
+	srv.mux.HandleFunc("/portal-api/themes/upload", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&uploads, 1)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithPortalVersionHeader("X-Portal-Version"),
+		WithCapabilities(map[Capability]string{CapabilityThemes: "1.2.0"}),
+	)
+	require.NoError(t, err)
+
+	assert.Empty(t, client.PortalVersion())
+	assert.Nil(t, client.Capabilities())
+	assert.False(t, client.Supports(CapabilityThemes), "unknown versions are not assumed to support gated capabilities")
+	assert.True(t, client.Supports(Capability("unlisted")))
+
+	_, err = client.Themes().UploadTheme(context.Background(), strings.NewReader("zip"))
+	require.ErrorIs(t, err, ErrUnsupported)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&uploads))
+
+	out, err := client.Ping(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.2.1", out.Version)
+	assert.Equal(t, []Capability{CapabilityThemes}, out.Capabilities)
+	assert.Equal(t, http.StatusOK, out.Meta.StatusCode)
+	assert.True(t, client.Supports(CapabilityThemes))
+
+	_, err = client.Ping(context.Background(), WithToken("WRONG"))
+
+	var apiErr APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Response.StatusCode)
+
+	_, err = client.Themes().UploadTheme(context.Background(), strings.NewReader("zip"), WithPortalVersion("1.1.0"))
+	require.ErrorIs(t, err, ErrUnsupported)
+
+	var unsupported UnsupportedError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, UnsupportedError{
+		Operation:  "Themes.UploadTheme",
+		Capability: CapabilityThemes,
+		Version:    "1.1.0",
+		MinVersion: "1.2.0",
+	}, unsupported)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&uploads))
+
+	_, err = client.Themes().UploadTheme(context.Background(), strings.NewReader("zip"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&uploads))
+}
+
+func TestCapabilities_NotConfigured(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/providers", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Portal-Version", "1.2.1")
+
+		_, err := w.Write([]byte(`[]`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(WithBaseURL(srv.srv.URL), WithToken("TOKEN"))
+	require.NoError(t, err)
+
+	out, err := client.Ping(context.Background())
+	require.NoError(t, err)
+
+	assert.Empty(t, out.Version, "no version header is read unless configured")
+	assert.True(t, client.Supports(CapabilityThemes))
+
+	_, err = New(WithBaseURL(srv.srv.URL), WithCapabilities(map[Capability]string{CapabilityThemes: "latest"}))
+	require.Error(t, err)
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want version
+		ok   bool
+	}{
+		{in: "1.8.3", want: version{1, 8, 3}, ok: true},
+		{in: "v1.8", want: version{1, 8, 0}, ok: true},
+		{in: "1.10.0-rc1", want: version{1, 10, 0}, ok: true},
+		{in: "", ok: false},
+		{in: "latest", ok: false},
+		{in: "1.2.3.4", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := parseVersion(tt.in)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.True(t, version{1, 9, 0}.less(version{1, 10, 0}))
+	assert.False(t, version{1, 10, 0}.less(version{1, 10, 0}))
+}
@@ -15,7 +15,7 @@ const (
 	pathCatalogue  = "/portal-api/catalogues/%d"
 )
 
-//go:generate mockery --name Catalogs --filename catalogs.go
+//go:generate mockery --name Catalogues --filename catalogs.go
 type Catalogues interface {
 	CreateCatalogue(ctx context.Context, input *CreateCatalogueInput, opts ...Option) (*CreateCatalogueOutput, error)
 	GetCatalogue(ctx context.Context, id int64, opts ...Option) (*GetCatalogueOutput, error)
@@ -29,12 +29,14 @@ type catalogues struct {
 }
 
 func (p catalogues) CreateCatalogue(ctx context.Context, input *CreateCatalogueInput, opts ...Option) (*CreateCatalogueOutput, error) {
+	ctx = withOperation(ctx, "Catalogues.CreateCatalogue")
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPost(ctx, pathCatalogues, bytes.NewReader(payload), nil)
+	resp, err := p.client.doCreate(ctx, pathCatalogues, payload, p.findCatalogueBySlug(input), opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -46,12 +48,16 @@ func (p catalogues) CreateCatalogue(ctx context.Context, input *CreateCatalogueI
 	}
 
 	return &CreateCatalogueOutput{
-		Data: &catalog,
+		Data:   &catalog,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p catalogues) GetCatalogue(ctx context.Context, id int64, opts ...Option) (*GetCatalogueOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathCatalogue, id), nil)
+	ctx = withOperation(ctx, "Catalogues.GetCatalogue", id)
+
+	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathCatalogue, id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -63,33 +69,33 @@ func (p catalogues) GetCatalogue(ctx context.Context, id int64, opts ...Option)
 
 	return &GetCatalogueOutput{
 		Data: &catalog,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p catalogues) ListCatalogues(ctx context.Context, options *ListCataloguesInput, opts ...Option) (*ListCataloguesOutput, error) {
-	resp, err := p.client.doGet(ctx, pathCatalogues, nil)
-	if err != nil {
-		return nil, err
-	}
+	ctx = withOperation(ctx, "Catalogues.ListCatalogues")
 
-	var catalogs []Catalogue
-
-	if err := resp.Unmarshal(&catalogs); err != nil {
+	catalogs, resp, err := listItems[Catalogue](ctx, p.client, pathCatalogues, nil, opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListCataloguesOutput{
 		Data: catalogs,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p catalogues) UpdateCatalogue(ctx context.Context, id int64, input *UpdateCatalogueInput, opts ...Option) (*UpdateCatalogueOutput, error) {
+	ctx = withOperation(ctx, "Catalogues.UpdateCatalogue", id)
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathCatalogue, id), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathCatalogue, id), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -101,17 +107,24 @@ func (p catalogues) UpdateCatalogue(ctx context.Context, id int64, input *Update
 	}
 
 	return &UpdateCatalogueOutput{
-		Data: &catalog,
+		Data:   &catalog,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p catalogues) DeleteCatalogue(ctx context.Context, id int64, opts ...Option) (*CatalogueOutput, error) {
-	_, err := p.client.doDelete(ctx, fmt.Sprintf(pathCatalogue, id), nil, nil)
+	ctx = withOperation(ctx, "Catalogues.DeleteCatalogue", id)
+
+	resp, err := p.client.doDelete(ctx, fmt.Sprintf(pathCatalogue, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &CatalogueOutput{}, nil
+	return &CatalogueOutput{
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
+	}, nil
 }
 
 type CatalogueInput struct {
@@ -135,6 +148,7 @@ type ListCataloguesInput struct{}
 
 type ListCataloguesOutput struct {
 	Data []Catalogue
+	Meta Meta
 }
 
 type Catalogue struct {
@@ -150,7 +164,9 @@ type Catalogue struct {
 }
 
 type CatalogueOutput struct {
-	Data *Catalogue
+	Data   *Catalogue
+	DryRun bool
+	Meta   Meta
 }
 
 type UpdateCatalogueOutput = CatalogueOutput
@@ -0,0 +1,134 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+// WithRequestCoalescing makes concurrent identical GETs, those with the same
+// token, path and query, share a single request to the portal. Every caller
+// gets its own copy of the response. A caller that gives up only stops
+// waiting; the shared request is cancelled once no caller is left. Copies of
+// the client share the in-flight requests.
+func WithRequestCoalescing() Option {
+	return func(c *Client) {
+		c.flights = &flightGroup{calls: map[string]*flight{}}
+	}
+}
+
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	resp     *APIResponse
+	attempts int
+	err      error
+}
+
+type sendFunc func(req *http.Request) (*APIResponse, int, error)
+
+// coalesce runs send for req, sharing the call with identical GETs already
+// in flight.
+func (c Client) coalesce(req *http.Request, send sendFunc) (*APIResponse, int, error) {
+	if c.flights == nil || req.Method != http.MethodGet {
+		return send(req)
+	}
+
+	sum := sha256.Sum256([]byte(req.Header.Get(headerAuthorization)))
+	key := hex.EncodeToString(sum[:]) + " " + cacheKey(req)
+
+	return c.flights.do(req, key, send)
+}
+
+func (g *flightGroup) do(req *http.Request, key string, send sendFunc) (*APIResponse, int, error) {
+	ctx := req.Context()
+
+	g.mu.Lock()
+
+	f, ok := g.calls[key]
+	if !ok {
+		// The shared request keeps the values of the first caller's context
+		// but not its cancellation, which is handled by counting waiters.
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+		f = &flight{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		g.calls[key] = f
+
+		go func() {
+			defer close(f.done)
+			defer cancel()
+
+			f.resp, f.attempts, f.err = send(req.WithContext(flightCtx))
+
+			g.forget(key, f)
+		}()
+	}
+
+	f.waiters++
+
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.resp.clone(), f.attempts, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			g.forgetLocked(key, f)
+		}
+
+		g.mu.Unlock()
+
+		return nil, 0, ctx.Err()
+	}
+}
+
+func (g *flightGroup) forget(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.forgetLocked(key, f)
+}
+
+func (g *flightGroup) forgetLocked(key string, f *flight) {
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+}
+
+// clone returns a copy of r that can be modified without affecting other
+// callers sharing the same response.
+func (r *APIResponse) clone() *APIResponse {
+	if r == nil {
+		return nil
+	}
+
+	out := *r
+	out.Body = append([]byte(nil), r.Body...)
+
+	if r.Response != nil {
+		resp := *r.Response
+		resp.Header = r.Response.Header.Clone()
+		out.Response = &resp
+	}
+
+	return &out
+}
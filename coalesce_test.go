@@ -0,0 +1,167 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCoalescingServer(t *testing.T, release <-chan struct{}, hits *int32) *server {
+	srv := NewServer(t)
+
+	srv.mux.HandleFunc("/portal-api/products/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		<-release
+
+		_, err := w.Write([]byte(`{"ID":1,"Name":"Product"}`))
+		assert.NoError(t, err)
+	})
+
+	return srv
+}
+
+// waitForFlight waits until the server has received a request.
+func waitForFlight(t *testing.T, hits *int32) {
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(hits) > 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestCoalescing_SharesRequest(t *testing.T) {
+	release := make(chan struct{})
+
+	var hits int32
+
+	srv := newCoalescingServer(t, release, &hits)
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithRequestCoalescing(),
+	)
+	require.NoError(t, err)
+
+	const callers = 10
+
+	var (
+		wg      sync.WaitGroup
+		started sync.WaitGroup
+		outs    = make([]*GetProductOutput, callers)
+	)
+
+	started.Add(callers)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			started.Done()
+
+			out, err := client.Products().GetProduct(context.Background(), 1)
+			assert.NoError(t, err)
+
+			outs[i] = out
+		}(i)
+	}
+
+	started.Wait()
+	waitForFlight(t, &hits)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	for i := 1; i < callers; i++ {
+		require.NotNil(t, outs[i])
+		assert.Equal(t, outs[0].Data, outs[i].Data)
+		assert.NotSame(t, outs[0].Data, outs[i].Data)
+	}
+}
+
+func TestCoalescing_DifferentTokens(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+
+	var hits int32
+
+	srv := newCoalescingServer(t, release, &hits)
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithRequestCoalescing(),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Products().GetProduct(context.Background(), 1)
+	require.NoError(t, err)
+
+	_, err = client.Products().GetProduct(context.Background(), 1, WithToken("OTHER"))
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestCoalescing_CallerCancellation(t *testing.T) {
+	release := make(chan struct{})
+
+	var hits int32
+
+	srv := newCoalescingServer(t, release, &hits)
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithRequestCoalescing(),
+		WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	firstErr := make(chan error, 1)
+
+	go func() {
+		_, err := client.Products().GetProduct(ctx, 1)
+		firstErr <- err
+	}()
+
+	waitForFlight(t, &hits)
+
+	second := make(chan *GetProductOutput, 1)
+
+	go func() {
+		out, err := client.Products().GetProduct(context.Background(), 1)
+		assert.NoError(t, err)
+		second <- out
+	}()
+
+	// Give the second caller time to join the flight before the first one
+	// leaves it.
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+
+	close(release)
+
+	out := <-second
+	require.NotNil(t, out)
+	assert.Equal(t, 1, out.Data.ID)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
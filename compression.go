@@ -0,0 +1,251 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+
+	headerAcceptEncoding  = "Accept-Encoding"
+	headerContentEncoding = "Content-Encoding"
+	headerContentLength   = "Content-Length"
+	encodingIdentity      = "identity"
+)
+
+// WithResponseCompression sets the content codings offered to the portal in
+// Accept-Encoding, in order of preference. Only gzip is offered by default;
+// call it with no encodings to ask for uncompressed responses. Responses are
+// decompressed by the client itself, so this works with any HTTPClient.
+func WithResponseCompression(encodings ...string) Option {
+	return func(c *Client) {
+		for _, enc := range encodings {
+			if !supportedEncoding(enc) {
+				c.optionErr = fmt.Errorf("unsupported content encoding %q", enc)
+				return
+			}
+		}
+
+		c.acceptEncodings = append([]string{}, encodings...)
+	}
+}
+
+// WithRequestCompression compresses request bodies of at least minSize bytes
+// with encoding and sets Content-Encoding. Only enable it for portals that
+// accept compressed request bodies.
+func WithRequestCompression(encoding string, minSize int) Option {
+	return func(c *Client) {
+		if !supportedEncoding(encoding) {
+			c.optionErr = fmt.Errorf("unsupported content encoding %q", encoding)
+			return
+		}
+
+		c.requestEncoding = encoding
+		c.requestCompressionMinSize = minSize
+	}
+}
+
+func supportedEncoding(enc string) bool {
+	return enc == EncodingGzip || enc == EncodingZstd
+}
+
+// compressRequest returns a copy of req with its body compressed when
+// request compression is enabled and the body is large enough. It also
+// returns the encoding used, if any.
+func (c Client) compressRequest(req *http.Request) (*http.Request, string, error) {
+	if c.requestEncoding == "" || req.GetBody == nil || req.Header.Get(headerContentEncoding) != "" {
+		return req, "", nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, "", err
+	}
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(raw) == 0 || len(raw) < c.requestCompressionMinSize {
+		return req, "", nil
+	}
+
+	data, err := compress(c.requestEncoding, raw)
+	if err != nil {
+		return nil, "", err
+	}
+
+	newReq := req.Clone(req.Context())
+	newReq.Body = io.NopCloser(bytes.NewReader(data))
+	newReq.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	newReq.ContentLength = int64(len(data))
+	newReq.Header.Set(headerContentEncoding, c.requestEncoding)
+
+	c.logCompression(req.Context(), "portal request compressed", c.requestEncoding, len(raw), len(data))
+
+	return newReq, c.requestEncoding, nil
+}
+
+func compress(encoding string, raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var w io.WriteCloser
+
+	switch encoding {
+	case EncodingGzip:
+		w = gzip.NewWriter(&buf)
+	case EncodingZstd:
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+
+		w = zw
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	if _, err := w.Write(raw); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompressResponse replaces the body of a compressed response with a
+// reader that decompresses it, and returns the encoding.
+func decompressResponse(resp *http.Response) (string, error) {
+	enc := strings.ToLower(strings.TrimSpace(resp.Header.Get(headerContentEncoding)))
+
+	var open func(r io.Reader) (io.ReadCloser, error)
+
+	switch enc {
+	case "", encodingIdentity:
+		return "", nil
+	case EncodingGzip:
+		open = func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		}
+	case EncodingZstd:
+		open = func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+
+			return d.IOReadCloser(), nil
+		}
+	default:
+		return "", fmt.Errorf("unsupported content encoding %q", enc)
+	}
+
+	resp.Body = &decodingBody{
+		body: resp.Body,
+		wire: countingReader{r: resp.Body},
+		open: open,
+	}
+	resp.Header.Del(headerContentEncoding)
+	resp.Header.Del(headerContentLength)
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	return enc, nil
+}
+
+// decodingBody decompresses a response body on first read, so that empty
+// bodies such as those of 304 responses need no valid compressed stream.
+type decodingBody struct {
+	body io.ReadCloser
+	wire countingReader
+	open func(r io.Reader) (io.ReadCloser, error)
+
+	r   io.ReadCloser
+	err error
+	n   int
+}
+
+func (b *decodingBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		b.r, b.err = b.open(&b.wire)
+	}
+
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	n, err := b.r.Read(p)
+	b.n += n
+
+	return n, err
+}
+
+func (b *decodingBody) Close() error {
+	if b.r != nil {
+		_ = b.r.Close()
+	}
+
+	return b.body.Close()
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+
+	return n, err
+}
+
+// logResponseCompression logs the sizes of a decompressed response body.
+func (c Client) logResponseCompression(ctx context.Context, resp *http.Response, encoding string) {
+	if body, ok := resp.Body.(*decodingBody); ok {
+		c.logCompression(ctx, "portal response decompressed", encoding, body.n, body.wire.n)
+	}
+}
+
+func (c Client) logCompression(ctx context.Context, msg, encoding string, size, compressed int) {
+	logger := c.debugLogger()
+	if logger == nil {
+		return
+	}
+
+	ratio := "n/a"
+	if size > 0 {
+		ratio = strconv.FormatFloat(float64(compressed)/float64(size), 'f', 2, 64)
+	}
+
+	logger.LogAttrs(
+		ctx,
+		slog.LevelDebug,
+		msg,
+		slog.String("encoding", encoding),
+		slog.Int("bytes", size),
+		slog.Int("compressed_bytes", compressed),
+		slog.String("ratio", ratio),
+		slog.String("operation", operationFromContext(ctx).name),
+	)
+}
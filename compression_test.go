@@ -0,0 +1,182 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const compressedPageContent = "page content "
+
+func writeCompressed(t *testing.T, w http.ResponseWriter, encoding string, body []byte) {
+	data, err := compress(encoding, body)
+	require.NoError(t, err)
+
+	w.Header().Set(headerContentEncoding, encoding)
+	_, err = w.Write(data)
+	assert.NoError(t, err)
+}
+
+func newCompressingServer(t *testing.T) *server {
+	srv := NewServer(t)
+
+	srv.mux.HandleFunc("/portal-api/pages/1", func(w http.ResponseWriter, r *http.Request) {
+		body := []byte(`{"ID":1,"Template":"` + strings.Repeat(compressedPageContent, 100) + `"}`)
+
+		accepted := r.Header.Get(headerAcceptEncoding)
+
+		switch {
+		case strings.HasPrefix(accepted, EncodingZstd):
+			writeCompressed(t, w, EncodingZstd, body)
+		case strings.HasPrefix(accepted, EncodingGzip):
+			writeCompressed(t, w, EncodingGzip, body)
+		default:
+			_, err := w.Write(body)
+			assert.NoError(t, err)
+		}
+	})
+
+	return srv
+}
+
+func TestCompression_Response(t *testing.T) {
+	srv := newCompressingServer(t)
+	defer srv.Close()
+
+	tt := map[string]struct {
+		opts     []Option
+		encoding string
+	}{
+		"gzip by default": {
+			encoding: EncodingGzip,
+		},
+		"gzip with custom http client": {
+			opts:     []Option{WithHTTPClient(&http.Client{})},
+			encoding: EncodingGzip,
+		},
+		"zstd": {
+			opts:     []Option{WithResponseCompression(EncodingZstd, EncodingGzip)},
+			encoding: EncodingZstd,
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			metrics := NewMemoryMetrics()
+
+			opts := append([]Option{WithBaseURL(srv.srv.URL), WithToken("TOKEN"), WithMetrics(metrics)}, tc.opts...)
+
+			client, err := New(opts...)
+			require.NoError(t, err)
+
+			out, err := client.Pages().GetPage(context.Background(), 1)
+			require.NoError(t, err)
+			assert.Equal(t, strings.Repeat(compressedPageContent, 100), out.Data.Template)
+
+			stats := metrics.Snapshot()["Pages.GetPage"]
+			assert.Equal(t, uint64(1), stats.CompressedResponses[tc.encoding])
+		})
+	}
+}
+
+func TestCompression_Request(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var encodings []string
+
+	srv.mux.HandleFunc("/portal-api/pages", func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get(headerContentEncoding))
+
+		var body io.Reader = r.Body
+
+		switch r.Header.Get(headerContentEncoding) {
+		case EncodingGzip:
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+
+			body = zr
+		case EncodingZstd:
+			zr, err := zstd.NewReader(r.Body)
+			require.NoError(t, err)
+
+			body = zr
+		}
+
+		var page Page
+		require.NoError(t, json.NewDecoder(body).Decode(&page))
+
+		_, err := w.Write([]byte(`{"ID":1}`))
+		assert.NoError(t, err)
+	})
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	metrics := NewMemoryMetrics()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithRequestCompression(EncodingGzip, 1024),
+		WithLogger(logger),
+		WithDebug(true),
+		WithMetrics(metrics),
+	)
+	require.NoError(t, err)
+
+	large := &CreatePageInput{Template: strings.Repeat(compressedPageContent, 200)}
+
+	_, err = client.Pages().CreatePage(context.Background(), large)
+	require.NoError(t, err)
+
+	_, err = client.Pages().CreatePage(context.Background(), &CreatePageInput{Template: "small"})
+	require.NoError(t, err)
+
+	_, err = client.Pages().CreatePage(context.Background(), large, WithRequestCompression(EncodingZstd, 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{EncodingGzip, "", EncodingZstd}, encodings)
+	assert.Contains(t, buf.String(), `"msg":"portal request compressed"`)
+	assert.Contains(t, buf.String(), `"encoding":"gzip"`)
+
+	stats := metrics.Snapshot()["Pages.CreatePage"]
+	assert.Equal(t, map[string]uint64{EncodingGzip: 1, EncodingZstd: 1}, stats.CompressedRequests)
+}
+
+func TestCompression_UnsupportedEncoding(t *testing.T) {
+	_, err := New(WithToken("TOKEN"), WithResponseCompression("br"))
+	assert.ErrorContains(t, err, `unsupported content encoding "br"`)
+
+	_, err = New(WithToken("TOKEN"), WithRequestCompression("deflate", 0))
+	assert.ErrorContains(t, err, `unsupported content encoding "deflate"`)
+
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var calls int32
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	})
+
+	client, err := New(WithBaseURL(srv.srv.URL), WithToken("TOKEN"))
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1, WithResponseCompression("br"))
+	assert.ErrorContains(t, err, `unsupported content encoding "br"`)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
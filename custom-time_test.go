@@ -53,7 +53,7 @@ func TestCustomTime_MarshalJSON(t *testing.T) {
 		{
 			name: "Success",
 			time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
-			want: []byte("2020-01-01 00:00"),
+			want: []byte(`"2020-01-01 00:00"`),
 		},
 	}
 	for _, tt := range tests {
@@ -61,7 +61,10 @@ func TestCustomTime_MarshalJSON(t *testing.T) {
 			ct := &CustomTime{
 				Time: tt.time,
 			}
-			got := ct.MarshalJSON()
+			got, err := ct.MarshalJSON()
+			if err != nil {
+				t.Fatalf("CustomTime.MarshalJSON() error = %v", err)
+			}
 			if string(got) != string(tt.want) {
 				t.Errorf("CustomTime.MarshalJSON() = %v, want %v", got, tt.want)
 			}
@@ -0,0 +1,91 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+)
+
+// Output is the result of Do.
+type Output[T any] struct {
+	// Data is decoded from the JSON response body. It is left zero when the
+	// portal returns an empty body.
+	Data   T
+	DryRun bool
+	Meta   Meta
+}
+
+// Do calls a portal endpoint that the library does not wrap yet and decodes
+// the JSON response into T. The request goes through the same
+// authentication, middlewares, retries, caching and error handling as the
+// built-in operations; an error status is returned as an APIError or a
+// StatusError. path is relative to the base URL, for example
+// "/portal-api/webhooks".
+//
+// body may be nil, an io.Reader or []byte sent as is, or any other value,
+// which is encoded as JSON. Middlewares see the request with an empty
+// Operation, and errors are prefixed with the method and path.
+func Do[T any](
+	ctx context.Context,
+	c *Client,
+	method string,
+	path string,
+	body interface{},
+	params url.Values,
+	opts ...Option,
+) (*Output[T], error) {
+	reqBody, err := requestBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.NewRequest(ctx, method, path, reqBody, params, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.performRequest(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Output[T]{
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
+	}
+
+	if len(bytes.TrimSpace(resp.Body)) == 0 {
+		return out, nil
+	}
+
+	if err := json.Unmarshal(resp.Body, &out.Data); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func requestBody(body interface{}) (io.Reader, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, nil
+	case io.Reader:
+		return b, nil
+	case []byte:
+		return bytes.NewReader(b), nil
+	case json.RawMessage:
+		return bytes.NewReader(b), nil
+	default:
+		payload, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+
+		return bytes.NewReader(payload), nil
+	}
+}
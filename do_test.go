@@ -0,0 +1,102 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhook struct {
+	ID  int64  `json:"ID,omitempty"`
+	URL string `json:"URL,omitempty"`
+}
+
+func TestDo(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "TOKEN", r.Header.Get(headerAuthorization))
+
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, "2", r.URL.Query().Get("page"))
+
+			_, err := w.Write([]byte(`[{"ID":1,"URL":"https://example.com/hook"}]`))
+			assert.NoError(t, err)
+		case http.MethodPost:
+			assert.Equal(t, "application/json", r.Header.Get(headerContentType))
+
+			var in webhook
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+
+			in.ID = 2
+
+			w.WriteHeader(http.StatusCreated)
+			assert.NoError(t, json.NewEncoder(w).Encode(in))
+		}
+	})
+
+	srv.mux.HandleFunc("/portal-api/webhooks/3", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+
+		_, err := w.Write([]byte(`{"status":"error","errors":["not found"]}`))
+		assert.NoError(t, err)
+	})
+
+	var ops []string
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMiddleware(func(next Doer) Doer {
+			return DoerFunc(func(req *Request) (*APIResponse, error) {
+				ops = append(ops, req.Method+" "+req.URL.Path)
+				return next.Do(req)
+			})
+		}),
+	)
+	require.NoError(t, err)
+
+	list, err := Do[[]webhook](context.Background(), client, http.MethodGet, "/portal-api/webhooks", nil, url.Values{"page": {"2"}})
+	require.NoError(t, err)
+	assert.Equal(t, []webhook{{ID: 1, URL: "https://example.com/hook"}}, list.Data)
+
+	created, err := Do[webhook](context.Background(), client, http.MethodPost, "/portal-api/webhooks", webhook{URL: "https://example.com/new"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, webhook{ID: 2, URL: "https://example.com/new"}, created.Data)
+
+	_, err = Do[webhook](context.Background(), client, http.MethodGet, "/portal-api/webhooks/3", nil, nil)
+
+	var apiErr APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Response.StatusCode)
+	assert.Equal(t, []string{"not found"}, apiErr.Errors)
+
+	assert.Equal(t, []string{
+		"GET /portal-api/webhooks",
+		"POST /portal-api/webhooks",
+		"GET /portal-api/webhooks/3",
+	}, ops)
+}
+
+func TestDo_DryRun(t *testing.T) {
+	client, err := New(WithBaseURL("http://portal.invalid"), WithToken("TOKEN"), WithDryRun())
+	require.NoError(t, err)
+
+	out, err := Do[webhook](context.Background(), client, http.MethodPut, "/portal-api/webhooks/1", []byte(`{"URL":"https://example.com"}`), nil)
+	require.NoError(t, err)
+
+	assert.True(t, out.DryRun)
+	assert.Equal(t, "https://example.com", out.Data.URL)
+	require.Len(t, client.PlannedChanges(), 1)
+	assert.Equal(t, "/portal-api/webhooks/1", client.PlannedChanges()[0].Path)
+}
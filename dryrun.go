@@ -0,0 +1,157 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+// HeaderDryRun is set on the synthetic responses returned in dry-run mode.
+const HeaderDryRun = "X-Portal-Dry-Run"
+
+// PlannedChange is a mutating request that was not sent because the client
+// is in dry-run mode.
+type PlannedChange struct {
+	// Operation is the operation name, for example "Orgs.CreateOrg".
+	Operation string
+	Method    string
+	Path      string
+	// Body is the request body, pretty-printed when it is JSON.
+	Body string
+}
+
+func (p PlannedChange) String() string {
+	if p.Body == "" {
+		return fmt.Sprintf("%v %v", p.Method, p.Path)
+	}
+
+	return fmt.Sprintf("%v %v\n%v", p.Method, p.Path, p.Body)
+}
+
+// WithDryRun records POST, PUT, PATCH and DELETE requests as planned changes
+// instead of sending them; GETs still reach the portal. Mutating operations
+// succeed with placeholder outputs whose DryRun field is set: their Data is
+// decoded from the request body, so server-assigned fields such as IDs are
+// zero. The planned changes are listed by Client.PlannedChanges and shared
+// by copies of the client.
+func WithDryRun() Option {
+	return func(c *Client) {
+		c.dryRun = &dryRunLog{}
+	}
+}
+
+type dryRunLog struct {
+	mu      sync.Mutex
+	changes []PlannedChange
+}
+
+// PlannedChanges returns the changes recorded in dry-run mode, in the order
+// they were planned.
+func (c *Client) PlannedChanges() []PlannedChange {
+	if c.dryRun == nil {
+		return nil
+	}
+
+	c.dryRun.mu.Lock()
+	defer c.dryRun.mu.Unlock()
+
+	return append([]PlannedChange(nil), c.dryRun.changes...)
+}
+
+func (c Client) dryRunApplies(req *http.Request) bool {
+	if c.dryRun == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// planChange records req as a planned change and returns the placeholder
+// response standing in for the portal's answer.
+func (c Client) planChange(req *http.Request) (*APIResponse, error) {
+	var body []byte
+
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+
+		if body, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+
+	change := PlannedChange{
+		Operation: operationFromContext(req.Context()).name,
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		Body:      prettyBody(req.Header.Get(headerContentType), body),
+	}
+
+	c.dryRun.mu.Lock()
+	c.dryRun.changes = append(c.dryRun.changes, change)
+	c.dryRun.mu.Unlock()
+
+	if c.logger != nil {
+		c.logger.LogAttrs(
+			req.Context(),
+			slog.LevelInfo,
+			"portal dry run",
+			slog.String("method", change.Method),
+			slog.String("path", change.Path),
+			slog.String("operation", change.Operation),
+		)
+	}
+
+	placeholder := []byte("{}")
+	if json.Valid(body) && bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		placeholder = body
+	}
+
+	header := http.Header{}
+	header.Set(HeaderDryRun, "true")
+	header.Set(headerContentType, "application/json")
+
+	return &APIResponse{
+		Body: placeholder,
+		Response: &http.Response{
+			Status:     http.StatusText(http.StatusOK),
+			StatusCode: http.StatusOK,
+			Header:     header,
+			Request:    req,
+		},
+		DryRun: true,
+	}, nil
+}
+
+func prettyBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", "  "); err == nil {
+		return buf.String()
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" {
+		mediaType = "unknown content type"
+	}
+
+	return fmt.Sprintf("<%d bytes of %v>", len(body), mediaType)
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %v %v in dry-run mode", r.Method, r.URL.Path)
+	})
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %v in dry-run mode", r.Method)
+		}
+
+		_, err := w.Write([]byte(`{"ID":1,"Name":"Default Org"}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithDryRun(),
+	)
+	require.NoError(t, err)
+
+	org, err := client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Default Org", org.Data.Name)
+	assert.False(t, org.DryRun)
+
+	created, err := client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+	assert.True(t, created.DryRun)
+	assert.Equal(t, "Acme", created.Data.Name)
+	assert.Zero(t, created.Data.ID)
+
+	deleted, err := client.Orgs().DeleteOrg(context.Background(), 1)
+	require.NoError(t, err)
+	assert.True(t, deleted.DryRun)
+
+	approved, err := client.ARs().ApproveAR(context.Background(), 3)
+	require.NoError(t, err)
+	assert.True(t, approved.DryRun)
+
+	uploaded, err := client.Themes().UploadTheme(context.Background(), strings.NewReader("zip"))
+	require.NoError(t, err)
+	assert.True(t, uploaded.DryRun)
+
+	changes := client.PlannedChanges()
+	require.Len(t, changes, 4)
+
+	assert.Equal(t, PlannedChange{
+		Operation: "Orgs.CreateOrg",
+		Method:    http.MethodPost,
+		Path:      "/portal-api/organisations",
+		Body:      "{\n  \"Name\": \"Acme\"\n}",
+	}, changes[0])
+	assert.Equal(t, "DELETE /portal-api/organisations/1", changes[1].String())
+	assert.Equal(t, "ARs.ApproveAR", changes[2].Operation)
+	assert.Contains(t, changes[3].Body, "bytes of multipart/form-data")
+}
+
+func TestDryRun_Disabled(t *testing.T) {
+	client, err := New(WithToken("TOKEN"))
+	require.NoError(t, err)
+
+	assert.Nil(t, client.PlannedChanges())
+}
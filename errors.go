@@ -1,6 +1,9 @@
 package portal
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+)
 
 type ClientError struct{}
 
@@ -18,13 +21,29 @@ func (e APIError) Error() string {
 		err = e.Errors[0]
 	}
 
-	return fmt.Sprintf(
+	msg := fmt.Sprintf(
 		"%v %v: %v %v",
 		e.Response.Request.Method,
 		e.Response.Request.URL,
 		e.Response.StatusCode,
 		err,
 	)
+
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request id %v)", e.RequestID)
+	}
+
+	return msg
+}
+
+// StatusError is returned for non-2xx responses whose body is not a portal
+// error document.
+type StatusError struct {
+	*APIResponse
+}
+
+func (e StatusError) Error() string {
+	return http.StatusText(e.Response.StatusCode)
 }
 
 type UnknownError struct {
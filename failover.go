@@ -0,0 +1,234 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultFailback = 30 * time.Second
+
+// WithBaseURLs sets a primary base URL and secondary ones to fail over to.
+// Requests go to the active endpoint, which starts as the primary. When an
+// attempt provably never reached the server, because the dial or DNS lookup
+// failed or the endpoint's circuit breaker rejected it, the client makes the
+// next endpoint active and sends the request there straight away. Other
+// failures, such as 5xx responses and read timeouts, are retried against the
+// same endpoint, as with a single base URL, since they may be caused by the
+// request rather than by the endpoint.
+//
+// After a failover the primary is tried again by the first request made
+// once the failback interval has passed; see WithFailback. Copies of the
+// client share the active endpoint. Changes are logged and reported to
+// WithEndpointChangeCallback. A later WithBaseURL, including one passed to a
+// single call, replaces the endpoints and turns failover off.
+func WithBaseURLs(primary string, secondaries ...string) Option {
+	return func(c *Client) {
+		endpoints := make([]string, 0, len(secondaries)+1)
+		for _, u := range append([]string{primary}, secondaries...) {
+			endpoints = append(endpoints, strings.TrimRight(u, "/"))
+		}
+
+		c.baseURL = primary
+		c.failover = &failover{endpoints: endpoints}
+	}
+}
+
+// WithFailback sets how long the client stays on a secondary endpoint
+// before trying the primary again. Zero keeps the secondary until it fails
+// in turn. Defaults to 30 seconds.
+func WithFailback(d time.Duration) Option {
+	return func(c *Client) {
+		c.failback = d
+	}
+}
+
+// WithEndpointChangeCallback registers fn to be called with the previous and
+// the new base URL whenever the active endpoint changes.
+func WithEndpointChangeCallback(fn func(from, to string)) Option {
+	return func(c *Client) {
+		c.onEndpointChange = fn
+	}
+}
+
+// ActiveEndpoint returns the base URL requests are currently sent to. It
+// does not fail back to the primary; the next request does.
+func (c Client) ActiveEndpoint() string {
+	if c.failover == nil {
+		return c.baseURL
+	}
+
+	return c.failover.activeEndpoint()
+}
+
+type failover struct {
+	endpoints []string
+
+	mu       sync.Mutex
+	active   int
+	switched time.Time
+}
+
+type endpointChange struct {
+	from, to string
+}
+
+func (f *failover) activeEndpoint() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.endpoints[f.active]
+}
+
+// current returns the active endpoint, switching back to the primary when
+// the failback interval has passed since the last failover.
+func (f *failover) current(failback time.Duration) (string, *endpointChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.active == 0 || failback <= 0 || time.Since(f.switched) < failback {
+		return f.endpoints[f.active], nil
+	}
+
+	change := &endpointChange{from: f.endpoints[f.active], to: f.endpoints[0]}
+	f.active = 0
+
+	return change.to, change
+}
+
+// fail marks endpoint as unreachable and returns the endpoint to use next.
+// When another request has already moved on from endpoint, its choice is
+// kept.
+func (f *failover) fail(endpoint string) (string, *endpointChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.endpoints[f.active] != endpoint {
+		return f.endpoints[f.active], nil
+	}
+
+	f.active = (f.active + 1) % len(f.endpoints)
+	f.switched = time.Now()
+
+	if f.endpoints[f.active] == endpoint {
+		return endpoint, nil
+	}
+
+	return f.endpoints[f.active], &endpointChange{from: endpoint, to: f.endpoints[f.active]}
+}
+
+// base returns the endpoint that u was built from, or "" when u points
+// elsewhere, for example because a call overrode the base URL.
+func (f *failover) base(u *url.URL) string {
+	s := u.String()
+
+	for _, endpoint := range f.endpoints {
+		if rest, ok := strings.CutPrefix(s, endpoint); ok && (rest == "" || strings.ContainsAny(rest[:1], "/?#")) {
+			return endpoint
+		}
+	}
+
+	return ""
+}
+
+// route returns a copy of req sent to endpoint instead of base.
+func route(ctx context.Context, req *http.Request, base, endpoint string) (*http.Request, error) {
+	if base == endpoint {
+		return req, nil
+	}
+
+	u, err := url.Parse(endpoint + strings.TrimPrefix(req.URL.String(), base))
+	if err != nil {
+		return nil, err
+	}
+
+	newReq := req.Clone(ctx)
+	newReq.URL = u
+	newReq.Host = ""
+
+	return newReq, nil
+}
+
+func (c Client) endpointChanged(ctx context.Context, change *endpointChange, reason string) {
+	if change == nil {
+		return
+	}
+
+	if c.logger != nil {
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "portal endpoint changed",
+			slog.String("from", change.from),
+			slog.String("to", change.to),
+			slog.String("reason", reason),
+		)
+	}
+
+	if c.onEndpointChange != nil {
+		c.onEndpointChange(change.from, change.to)
+	}
+}
+
+// neverSent reports whether err proves that no part of the request reached
+// the server.
+func neverSent(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// endpoint returns the base URL req was built from and the endpoint it
+// should be sent to. Both are empty when failover does not apply to req.
+func (c Client) endpoint(ctx context.Context, req *http.Request) (string, string) {
+	if c.failover == nil {
+		return "", ""
+	}
+
+	base := c.failover.base(req.URL)
+	if base == "" {
+		return "", ""
+	}
+
+	endpoint, change := c.failover.current(c.failback)
+	c.endpointChanged(ctx, change, "failback")
+
+	return base, endpoint
+}
+
+// failOver moves a request off endpoint after an attempt that failed with
+// err without reaching the server, trying each endpoint at most once per
+// request.
+func (c Client) failOver(ctx context.Context, endpoint string, failovers int, err error) (string, bool) {
+	if endpoint == "" || failovers >= len(c.failover.endpoints)-1 || ctx.Err() != nil {
+		return "", false
+	}
+
+	if err == nil || !neverSent(err) {
+		return "", false
+	}
+
+	next, change := c.failover.fail(endpoint)
+	c.endpointChanged(ctx, change, err.Error())
+
+	if next == endpoint {
+		return "", false
+	}
+
+	return next, true
+}
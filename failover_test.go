@@ -0,0 +1,276 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deadURL returns the URL of a server that is no longer listening.
+func deadURL(t *testing.T) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	return srv.URL
+}
+
+func TestFailover_GetMovesToNextEndpoint(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var calls int32
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		_, err := w.Write([]byte(`{"ID":1,"Name":"org"}`))
+		assert.NoError(t, err)
+	})
+
+	primary := deadURL(t)
+
+	var (
+		mu      sync.Mutex
+		changes [][2]string
+	)
+
+	client, err := New(
+		WithBaseURLs(primary, srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxRetries(0),
+		WithEndpointChangeCallback(func(from, to string) {
+			mu.Lock()
+			changes = append(changes, [2]string{from, to})
+			mu.Unlock()
+		}),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		out, err := client.Orgs().GetOrg(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, "org", out.Data.Name)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, srv.srv.URL, client.ActiveEndpoint())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, [][2]string{{primary, srv.srv.URL}}, changes)
+}
+
+func TestFailover_MutatingCallsFailOverOnlyWhenNeverSent(t *testing.T) {
+	secondary := NewServer(t)
+	defer secondary.Close()
+
+	var secondaryCalls int32
+
+	secondary.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&secondaryCalls, 1)
+
+		_, err := w.Write([]byte(`{"ID":1,"Name":"updated"}`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("connection dropped after sending", func(t *testing.T) {
+		primary := NewServer(t)
+		defer primary.Close()
+
+		var primaryCalls int32
+
+		primary.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&primaryCalls, 1)
+
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+		})
+
+		client, err := New(
+			WithBaseURLs(primary.srv.URL, secondary.srv.URL),
+			WithToken("TOKEN"),
+			WithMaxRetries(0),
+		)
+		require.NoError(t, err)
+
+		_, err = client.Orgs().UpdateOrg(context.Background(), 1, &UpdateOrgInput{Name: "updated"})
+		require.Error(t, err)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&primaryCalls))
+		assert.Equal(t, int32(0), atomic.LoadInt32(&secondaryCalls))
+		assert.Equal(t, primary.srv.URL, client.ActiveEndpoint())
+	})
+
+	t.Run("dial failed", func(t *testing.T) {
+		client, err := New(
+			WithBaseURLs(deadURL(t), secondary.srv.URL),
+			WithToken("TOKEN"),
+			WithMaxRetries(0),
+		)
+		require.NoError(t, err)
+
+		out, err := client.Orgs().UpdateOrg(context.Background(), 1, &UpdateOrgInput{Name: "updated"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "updated", out.Data.Name)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&secondaryCalls))
+	})
+}
+
+func TestFailover_Failback(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"ID":1}`))
+		assert.NoError(t, err)
+	})
+
+	primary := deadURL(t)
+
+	var (
+		mu      sync.Mutex
+		changes [][2]string
+	)
+
+	client, err := New(
+		WithBaseURLs(primary, srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxRetries(0),
+		WithFailback(20*time.Millisecond),
+		WithEndpointChangeCallback(func(from, to string) {
+			mu.Lock()
+			changes = append(changes, [2]string{from, to})
+			mu.Unlock()
+		}),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, srv.srv.URL, client.ActiveEndpoint())
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, srv.srv.URL, client.ActiveEndpoint(), "reading the endpoint does not fail back")
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, srv.srv.URL, client.ActiveEndpoint())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, [][2]string{
+		{primary, srv.srv.URL},
+		{srv.srv.URL, primary},
+		{primary, srv.srv.URL},
+	}, changes)
+}
+
+func TestFailover_AllEndpointsDown(t *testing.T) {
+	client, err := New(
+		WithBaseURLs(deadURL(t), deadURL(t)),
+		WithToken("TOKEN"),
+		WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.Error(t, err)
+}
+
+func TestFailover_NotOnServerError(t *testing.T) {
+	primary := NewServer(t)
+	defer primary.Close()
+
+	secondary := NewServer(t)
+	defer secondary.Close()
+
+	var primaryCalls, secondaryCalls int32
+
+	primary.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&primaryCalls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, err := w.Write([]byte(`{"ID":1}`))
+		assert.NoError(t, err)
+	})
+
+	secondary.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&secondaryCalls, 1)
+	})
+
+	client, err := New(
+		WithBaseURLs(primary.srv.URL, secondary.srv.URL),
+		WithToken("TOKEN"),
+		WithRetryPolicy(ExponentialBackoff{}),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&primaryCalls), "5xx responses are retried on the same endpoint")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&secondaryCalls))
+	assert.Equal(t, primary.srv.URL, client.ActiveEndpoint())
+}
+
+func TestFailover_NotOnReadTimeout(t *testing.T) {
+	primary := NewServer(t)
+	defer primary.Close()
+
+	secondary := NewServer(t)
+	defer secondary.Close()
+
+	primary.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	var secondaryCalls int32
+
+	secondary.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&secondaryCalls, 1)
+	})
+
+	client, err := New(
+		WithBaseURLs(primary.srv.URL, secondary.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxRetries(0),
+		WithReadTimeout(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.Error(t, err)
+	assert.Equal(t, primary.srv.URL, client.ActiveEndpoint())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&secondaryCalls))
+}
+
+func TestFailover_WithBaseURLTurnsItOff(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURLs(deadURL(t), srv.srv.URL),
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	assert.Nil(t, client.failover)
+	assert.Equal(t, srv.srv.URL, client.ActiveEndpoint())
+}
@@ -0,0 +1,307 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const headerIdempotencyKey = "Idempotency-Key"
+
+// WithIdempotentCreate makes CreateOrg, CreateUser, CreateCatalogue and
+// CreatePage safe to retry. Each call sends an Idempotency-Key header that
+// stays the same across its retries. When a create fails ambiguously, with
+// a network error, a timeout or a 5xx response, the client looks the entity
+// up by its natural key (org name, user email, catalogue slug or page path)
+// and returns it if it exists; otherwise it retries the create. The lookup
+// also runs after the last attempt, before its error is returned. Responses
+// saying the create was not applied, 429 and 503 with Retry-After, are
+// retried without a lookup. The usual retries are disabled for these calls
+// and replaced by this loop, which honours WithMaxRetries and the retry
+// policy's backoff.
+func WithIdempotentCreate() Option {
+	return func(c *Client) {
+		c.idempotentCreate = true
+	}
+}
+
+// finder looks up an entity by its natural key. It reports false when the
+// entity does not exist.
+type finder func(ctx context.Context, opts ...Option) (interface{}, bool, error)
+
+// doCreate POSTs payload to path, following the idempotent create protocol
+// when it is enabled. An entity found by find after an ambiguous failure is
+// returned as the body of a synthetic 200 response, whose metadata reports
+// the create attempts and the request ID they were sent with.
+func (c Client) doCreate(ctx context.Context, path string, payload []byte, find finder, opts ...Option) (*APIResponse, error) {
+	client := c.copy(opts...)
+	if !client.idempotentCreate || client.dryRun != nil || find == nil {
+		return c.doPost(ctx, path, bytes.NewReader(payload), nil, opts...)
+	}
+
+	createOpts := append(opts[:len(opts):len(opts)],
+		WithHeaders(map[string]string{headerIdempotencyKey: newRequestID()}),
+		WithMaxRetries(0),
+	)
+
+	createCtx, requestID := client.withCreateRequestID(ctx)
+
+	var (
+		policy   = client.policy()
+		start    = time.Now()
+		attempts int
+	)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.doPost(createCtx, path, bytes.NewReader(payload), nil, createOpts...)
+		if err == nil {
+			return resp, nil
+		}
+
+		failed := errorResponse(err)
+		attempts += max(failed.Meta().Attempts, 1)
+
+		if failed != nil && failed.RequestID != "" {
+			requestID = failed.RequestID
+		}
+
+		switch {
+		case notApplied(failed):
+		case ambiguousFailure(ctx, err):
+			client.logAmbiguousCreate(ctx, path, attempt, err)
+
+			if entity, found, findErr := find(ctx, opts...); findErr == nil && found {
+				return foundResponse(entity, requestID, attempts, time.Since(start))
+			}
+		default:
+			return nil, err
+		}
+
+		if attempt >= client.maxRetries {
+			return nil, err
+		}
+
+		wait, retry := retryCreate(policy, attempt, failed, err)
+		if !retry {
+			return nil, err
+		}
+
+		if sleepErr := sleep(ctx, wait); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// withCreateRequestID returns ctx carrying the request ID that every
+// attempt of a create is sent with, so that a found entity can be traced
+// back to them.
+func (c Client) withCreateRequestID(ctx context.Context) (context.Context, string) {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return ctx, id
+	}
+
+	id := c.requestID(ctx)
+	if id == "" {
+		return ctx, ""
+	}
+
+	return ContextWithRequestID(ctx, id), id
+}
+
+// retryCreate asks policy whether to retry a create that failed with err.
+// Status errors are handed over as responses, so that the policy can honour
+// Retry-After.
+func retryCreate(policy RetryPolicy, attempt int, failed *APIResponse, err error) (time.Duration, bool) {
+	if failed != nil {
+		return policy.Retry(attempt, failed.Response, nil)
+	}
+
+	return policy.Retry(attempt, nil, err)
+}
+
+func (c Client) logAmbiguousCreate(ctx context.Context, path string, attempt int, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	c.logger.LogAttrs(
+		ctx,
+		slog.LevelWarn,
+		"portal create failed ambiguously, looking up entity",
+		slog.String("path", path),
+		slog.String("operation", operationFromContext(ctx).name),
+		slog.Int("attempt", attempt+1),
+		slog.String("error", err.Error()),
+	)
+}
+
+func foundResponse(entity interface{}, requestID string, attempts int, duration time.Duration) (*APIResponse, error) {
+	body, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIResponse{
+		Body: body,
+		Response: &http.Response{
+			Status:     http.StatusText(http.StatusOK),
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+		},
+		RequestID: requestID,
+		attempts:  attempts,
+		duration:  duration,
+	}, nil
+}
+
+// errorResponse returns the response carried by an APIError or a
+// StatusError, or nil for other errors.
+func errorResponse(err error) *APIResponse {
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.APIResponse
+	}
+
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.APIResponse
+	}
+
+	return nil
+}
+
+// notApplied reports whether the portal answered a create with a status
+// saying it was not applied and may be retried: 429, or 503 with
+// Retry-After.
+func notApplied(failed *APIResponse) bool {
+	if failed == nil || failed.Response == nil {
+		return false
+	}
+
+	switch failed.Response.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable:
+		return failed.Response.Header.Get(headerRetryAfter) != ""
+	default:
+		return false
+	}
+}
+
+// ambiguousFailure reports whether a create that failed with err may
+// nevertheless have been applied by the portal.
+func ambiguousFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+
+	if resp := errorResponse(err); resp != nil {
+		return resp.Response.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// findItem lists every item at path, without pagination, and returns the
+// first one match accepts. Item callbacks are ignored so that the lookup
+// sees the items.
+func findItem[T any](
+	ctx context.Context,
+	c *Client,
+	operation string,
+	path string,
+	match func(item T) bool,
+	opts ...Option,
+) (interface{}, bool, error) {
+	ctx = withOperation(ctx, operation)
+
+	items, _, err := listItems[T](ctx, c, path, unpaginated(), append(opts[:len(opts):len(opts)], withoutItemCallbacks())...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, item := range items {
+		if match(item) {
+			return item, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+func (p orgs) findOrgByName(input *OrgInput) finder {
+	if input == nil || input.Name == "" {
+		return nil
+	}
+
+	name := input.Name
+
+	return func(ctx context.Context, opts ...Option) (interface{}, bool, error) {
+		return findItem(ctx, p.client, "Orgs.ListOrgs", pathOrgs, func(org Org) bool {
+			return org.Name == name
+		}, opts...)
+	}
+}
+
+func (p users) findUserByEmail(input *UserInput) finder {
+	if input == nil || input.Email == "" {
+		return nil
+	}
+
+	email := input.Email
+
+	return func(ctx context.Context, opts ...Option) (interface{}, bool, error) {
+		return findItem(ctx, p.client, "Users.ListUsers", pathUsers, func(user User) bool {
+			return strings.EqualFold(user.Email, email)
+		}, opts...)
+	}
+}
+
+// findCatalogueBySlug matches on NameWithSlug, or on Name when the input
+// has no slug.
+func (p catalogues) findCatalogueBySlug(input *CatalogueInput) finder {
+	if input == nil || input.NameWithSlug == "" && input.Name == "" {
+		return nil
+	}
+
+	return func(ctx context.Context, opts ...Option) (interface{}, bool, error) {
+		return findItem(ctx, p.client, "Catalogues.ListCatalogues", pathCatalogues, func(catalogue Catalogue) bool {
+			return input.NameWithSlug != "" && catalogue.NameWithSlug == input.NameWithSlug ||
+				input.NameWithSlug == "" && catalogue.Name == input.Name
+		}, opts...)
+	}
+}
+
+func (p pages) findPageByPath(input *PageInput) finder {
+	if input == nil || input.Path == "" {
+		return nil
+	}
+
+	path := input.Path
+
+	return func(ctx context.Context, opts ...Option) (interface{}, bool, error) {
+		return findItem(ctx, p.client, "Pages.ListPages", pathPages, func(page Page) bool {
+			return page.Path == path
+		}, opts...)
+	}
+}
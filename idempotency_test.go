@@ -0,0 +1,326 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orgsServer stores created organisations. fail decides how the n-th create
+// (from zero) behaves: it returns the status to answer with, zero for a
+// normal response, and whether the organisation is stored anyway. Creates
+// listed in slow are answered late, after the organisation is stored.
+type orgsServer struct {
+	*server
+
+	mu    sync.Mutex
+	orgs  []Org
+	keys  []string
+	lists int
+}
+
+func newOrgsServer(t *testing.T, fail func(n int) (int, bool), slow ...int) *orgsServer {
+	s := &orgsServer{server: NewServer(t)}
+
+	s.mux.HandleFunc("/portal-api/organisations", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+
+		if r.Method == http.MethodGet {
+			s.lists++
+
+			// Like the portal, list in pages unless asked not to.
+			orgs := s.orgs
+			if r.URL.Query().Get("p") != "-2" && len(orgs) > 2 {
+				orgs = orgs[:2]
+			}
+
+			assert.NoError(t, json.NewEncoder(w).Encode(orgs))
+			s.mu.Unlock()
+
+			return
+		}
+
+		var input OrgInput
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+
+		n := len(s.keys)
+		s.keys = append(s.keys, r.Header.Get(headerIdempotencyKey))
+
+		status, store := fail(n)
+		org := Org{ID: int64(len(s.orgs) + 1), Name: input.Name}
+
+		if store || status == 0 {
+			s.orgs = append(s.orgs, org)
+		}
+
+		s.mu.Unlock()
+
+		if status == http.StatusTooManyRequests {
+			w.Header().Set(headerRetryAfter, "0")
+		}
+
+		for _, i := range slow {
+			if i == n {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(org))
+	})
+
+	return s
+}
+
+func TestIdempotentCreate_TimeoutAfterCommit(t *testing.T) {
+	// The first create is applied but its answer arrives too late.
+	srv := newOrgsServer(t, func(n int) (int, bool) {
+		return 0, true
+	}, 0)
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithIdempotentCreate(),
+		WithReadTimeout(50*time.Millisecond),
+		WithRetryPolicy(ExponentialBackoff{}),
+	)
+	require.NoError(t, err)
+
+	out, err := client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", out.Data.Name)
+	assert.Equal(t, int64(1), out.Data.ID)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	assert.Len(t, srv.orgs, 1, "exactly one organisation is created")
+	require.Len(t, srv.keys, 1)
+	assert.NotEmpty(t, srv.keys[0])
+	assert.Equal(t, 1, srv.lists)
+}
+
+func TestIdempotentCreate_RetryWithSameKey(t *testing.T) {
+	srv := newOrgsServer(t, func(n int) (int, bool) {
+		if n == 0 {
+			return http.StatusBadGateway, false
+		}
+
+		return 0, true
+	})
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithIdempotentCreate(),
+		WithRetryPolicy(ExponentialBackoff{}),
+	)
+	require.NoError(t, err)
+
+	out, err := client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", out.Data.Name)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	assert.Len(t, srv.orgs, 1)
+	require.Len(t, srv.keys, 2)
+	assert.Equal(t, srv.keys[0], srv.keys[1])
+	assert.Equal(t, 1, srv.lists)
+}
+
+func TestIdempotentCreate_NotAmbiguous(t *testing.T) {
+	srv := newOrgsServer(t, func(n int) (int, bool) {
+		return http.StatusUnprocessableEntity, false
+	})
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithIdempotentCreate(),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{Name: "Acme"})
+	require.Error(t, err)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	assert.Len(t, srv.keys, 1)
+	assert.Zero(t, srv.lists)
+}
+
+func TestIdempotentCreate_Disabled(t *testing.T) {
+	srv := newOrgsServer(t, func(n int) (int, bool) {
+		return 0, true
+	})
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	require.Len(t, srv.keys, 1)
+	assert.Empty(t, srv.keys[0])
+}
+
+func TestIdempotentCreate_LookupAfterLastAttempt(t *testing.T) {
+	srv := newOrgsServer(t, func(n int) (int, bool) {
+		return http.StatusBadGateway, true
+	})
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithIdempotentCreate(),
+		WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+
+	out, err := client.Orgs().CreateOrg(ContextWithRequestID(context.Background(), "req-1"), &CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", out.Data.Name)
+	assert.Equal(t, 1, out.Meta.Attempts)
+	assert.Equal(t, "req-1", out.Meta.RequestID)
+	assert.Positive(t, out.Meta.Duration)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	assert.Len(t, srv.keys, 1)
+	assert.Equal(t, 1, srv.lists)
+}
+
+type recordingPolicy struct {
+	mu    sync.Mutex
+	resps []*http.Response
+}
+
+func (p *recordingPolicy) Retry(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if resp != nil && resp.StatusCode < http.StatusInternalServerError {
+		return 0, false
+	}
+
+	p.resps = append(p.resps, resp)
+
+	return 0, true
+}
+
+func TestIdempotentCreate_PolicySeesResponse(t *testing.T) {
+	srv := newOrgsServer(t, func(n int) (int, bool) {
+		if n == 0 {
+			return http.StatusServiceUnavailable, false
+		}
+
+		return 0, true
+	})
+	defer srv.Close()
+
+	policy := &recordingPolicy{}
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithIdempotentCreate(),
+		WithRetryPolicy(policy),
+	)
+	require.NoError(t, err)
+
+	out, err := client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", out.Data.Name)
+
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+
+	require.Len(t, policy.resps, 1)
+	require.NotNil(t, policy.resps[0])
+	assert.Equal(t, http.StatusServiceUnavailable, policy.resps[0].StatusCode)
+}
+
+func TestIdempotentCreate_RetriesTooManyRequests(t *testing.T) {
+	srv := newOrgsServer(t, func(n int) (int, bool) {
+		if n == 0 {
+			return http.StatusTooManyRequests, false
+		}
+
+		return 0, false
+	})
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithIdempotentCreate(),
+	)
+	require.NoError(t, err)
+
+	out, err := client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", out.Data.Name)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	assert.Len(t, srv.keys, 2)
+	assert.Zero(t, srv.lists, "a 429 was not applied, so there is nothing to look up")
+}
+
+func TestIdempotentCreate_LookupBeyondFirstPage(t *testing.T) {
+	// The create is applied but answered with a 502.
+	srv := newOrgsServer(t, func(n int) (int, bool) {
+		return http.StatusBadGateway, true
+	})
+	defer srv.Close()
+
+	srv.orgs = []Org{{ID: 1, Name: "One"}, {ID: 2, Name: "Two"}, {ID: 3, Name: "Three"}}
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithIdempotentCreate(),
+		WithRetryPolicy(ExponentialBackoff{}),
+		WithItemCallback(func(org Org) error { return nil }),
+	)
+	require.NoError(t, err)
+
+	out, err := client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), out.Data.ID)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	assert.Len(t, srv.keys, 1, "the entity was found, so the create is not retried")
+}
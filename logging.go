@@ -0,0 +1,230 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	redacted         = "[REDACTED]"
+	maxLoggedBodyLen = 4096
+)
+
+var (
+	sensitiveHeaders = []string{
+		headerAuthorization,
+		"Cookie",
+		"Set-Cookie",
+		"Proxy-Authorization",
+		"X-Api-Key",
+	}
+
+	sensitiveKeys = []string{
+		"token",
+		"secret",
+		"password",
+		"credential",
+		"apikey",
+	}
+)
+
+// WithLogger sets the logger used for request logs. Each attempt is logged
+// at info level, failed attempts at warn level. With WithDebug, request and
+// response bodies are logged at debug level with secrets redacted. Without a
+// logger the client logs nothing.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+func (c Client) logAttempt(
+	ctx context.Context,
+	req *http.Request,
+	resp *http.Response,
+	err error,
+	attempt int,
+	duration time.Duration,
+) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Duration("duration", duration),
+		slog.Int("attempt", attempt+1),
+		slog.String("operation", operationFromContext(ctx).name),
+	}
+
+	if c.failover != nil {
+		attrs = append(attrs, slog.String("endpoint", req.URL.Scheme+"://"+req.URL.Host))
+	}
+
+	if id := req.Header.Get(headerRequestID); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+
+	level := slog.LevelInfo
+
+	switch {
+	case err != nil:
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", err.Error()))
+	case resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests:
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	default:
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+
+	c.logger.LogAttrs(ctx, level, "portal request", attrs...)
+}
+
+// debugLogger returns the logger for WithDebug output, or nil when debug
+// logging is off or no logger is set.
+func (c Client) debugLogger() *slog.Logger {
+	if !c.debug {
+		return nil
+	}
+
+	return c.logger
+}
+
+func (c Client) dumpRequest(ctx context.Context, req *http.Request) {
+	logger := c.debugLogger()
+	if logger == nil {
+		return
+	}
+
+	var body []byte
+
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err == nil {
+			body, _ = io.ReadAll(r)
+		}
+	}
+
+	logger.LogAttrs(
+		ctx,
+		slog.LevelDebug,
+		"portal request dump",
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.String("operation", operationFromContext(ctx).name),
+		slog.Any("headers", redactHeaders(req.Header)),
+		slog.String("body", redactBody(req.Header.Get(headerContentType), body)),
+	)
+}
+
+func (c Client) dumpResponse(ctx context.Context, resp *APIResponse) {
+	logger := c.debugLogger()
+	if logger == nil || resp == nil || resp.Response == nil {
+		return
+	}
+
+	logger.LogAttrs(
+		ctx,
+		slog.LevelDebug,
+		"portal response dump",
+		slog.Int("status", resp.Response.StatusCode),
+		slog.String("operation", operationFromContext(ctx).name),
+		slog.Any("headers", redactHeaders(resp.Response.Header)),
+		slog.String("body", redactBody(resp.Response.Header.Get(headerContentType), resp.Body)),
+	)
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+
+	for k, v := range h {
+		headers[k] = strings.Join(v, ", ")
+	}
+
+	for _, k := range sensitiveHeaders {
+		k = http.CanonicalHeaderKey(k)
+		if _, ok := headers[k]; ok {
+			headers[k] = redacted
+		}
+	}
+
+	return headers
+}
+
+// redactBody renders a body for the debug log. JSON bodies have the values of
+// sensitive keys replaced; other content types are summarised.
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "" && mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return fmt.Sprintf("<%d bytes of %v>", len(body), mediaType)
+	}
+
+	var v interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Sprintf("<%d bytes of unparseable body>", len(body))
+	}
+
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return fmt.Sprintf("<%d bytes>", len(body))
+	}
+
+	if len(out) > maxLoggedBodyLen {
+		return string(out[:maxLoggedBodyLen]) + "...(truncated)"
+	}
+
+	return string(out)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, value := range t {
+			if isSensitiveKey(k) {
+				t[k] = redacted
+				continue
+			}
+
+			t[k] = redactValue(value)
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+	}
+
+	return v
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+
+	return false
+}
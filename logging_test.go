@@ -0,0 +1,120 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogging_Request(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/users/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"ID":1,"Email":"jane@example.com","JWTToken":"jwt-secret"}`))
+		assert.NoError(t, err)
+	})
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithLogger(logger),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Users().UpdateUser(context.Background(), 1, &UpdateUserInput{Email: "jane@example.com"})
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `"msg":"portal request"`)
+	assert.Contains(t, out, `"method":"PUT"`)
+	assert.Contains(t, out, `"path":"/portal-api/users/1"`)
+	assert.Contains(t, out, `"status":200`)
+	assert.Contains(t, out, `"attempt":1`)
+	assert.Contains(t, out, `"operation":"Users.UpdateUser"`)
+	assert.Contains(t, out, `"duration"`)
+	assert.NotContains(t, out, "portal request dump")
+	assert.NotContains(t, out, "jane@example.com")
+}
+
+func TestLogging_DebugRedactsSecrets(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"ID":1,"Email":"jane@example.com","JWTToken":"jwt-secret"}`))
+		assert.NoError(t, err)
+	})
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithLogger(logger),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Users().CreateUser(
+		context.Background(),
+		&CreateUserInput{Email: "jane@example.com", First: "Jane"},
+		WithDebug(true),
+	)
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "portal request dump")
+	assert.Contains(t, out, "portal response dump")
+	assert.Contains(t, out, "jane@example.com")
+	assert.Contains(t, out, redacted)
+	assert.NotContains(t, out, "jwt-secret")
+	assert.NotContains(t, out, "TOKEN")
+}
+
+func TestRedactBody(t *testing.T) {
+	tt := map[string]struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		"nested json": {
+			contentType: "application/json",
+			body:        `{"Name":"app","Credentials":[{"OAuthClientSecret":"s3cr3t","ID":1}],"Password":"pw"}`,
+			want:        `{"Credentials":"[REDACTED]","Name":"app","Password":"[REDACTED]"}`,
+		},
+		"non-string secrets": {
+			contentType: "application/json",
+			body:        `{"Apps":[{"Name":"app","ApiKey":12345,"ClientSecret":{"Value":"s3cr3t"},"Token":null}]}`,
+			want:        `{"Apps":[{"ApiKey":"[REDACTED]","ClientSecret":"[REDACTED]","Name":"app","Token":"[REDACTED]"}]}`,
+		},
+		"binary": {
+			contentType: "application/zip",
+			body:        "PK",
+			want:        "<2 bytes of application/zip>",
+		},
+		"empty": {
+			contentType: "application/json",
+			want:        "",
+		},
+	}
+
+	for k, v := range tt {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, v.want, redactBody(v.contentType, []byte(v.body)))
+		})
+	}
+}
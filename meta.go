@@ -0,0 +1,85 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const headerRateLimitLimit = "X-RateLimit-Limit"
+
+// Meta describes the HTTP exchange behind an operation's output.
+type Meta struct {
+	StatusCode int
+	Header     http.Header
+	// RequestID is the X-Request-ID echoed by the portal, if any.
+	RequestID string
+	// Attempts is the number of HTTP attempts made, retries and failovers
+	// included. It is zero in dry-run mode.
+	Attempts int
+	// Duration is how long the call took, backoff included.
+	Duration time.Duration
+	// RateLimit is nil when the portal sent no rate-limit headers.
+	RateLimit *RateLimit
+}
+
+// RateLimit holds the X-RateLimit-* headers of a response. Fields for
+// headers that were not sent are zero.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// Meta returns the metadata of the response. Errors returned by operations
+// embed the APIResponse, so their Meta is available too.
+func (a *APIResponse) Meta() Meta {
+	if a == nil {
+		return Meta{}
+	}
+
+	m := Meta{
+		RequestID: a.RequestID,
+		Attempts:  a.attempts,
+		Duration:  a.duration,
+	}
+
+	if a.Response != nil {
+		m.StatusCode = a.Response.StatusCode
+		m.Header = a.Response.Header
+		m.RateLimit = parseRateLimit(a.Response)
+	}
+
+	return m
+}
+
+func parseRateLimit(resp *http.Response) *RateLimit {
+	var (
+		rl    RateLimit
+		found bool
+	)
+
+	if v, err := strconv.Atoi(resp.Header.Get(headerRateLimitLimit)); err == nil {
+		rl.Limit = v
+		found = true
+	}
+
+	if v, err := strconv.Atoi(resp.Header.Get(headerRateLimitRemaining)); err == nil {
+		rl.Remaining = v
+		found = true
+	}
+
+	if reset, ok := rateLimitReset(resp, time.Now()); ok {
+		rl.Reset = reset
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+
+	return &rl
+}
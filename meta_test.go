@@ -0,0 +1,92 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeta(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var calls int32
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRequestID, r.Header.Get(headerRequestID))
+
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+
+		_, err := w.Write([]byte(`{"ID":1,"Name":"org"}`))
+		assert.NoError(t, err)
+	})
+
+	srv.mux.HandleFunc("/portal-api/organisations", func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`[{"ID":1,"Name":"org"}]`))
+		assert.NoError(t, err)
+	})
+
+	srv.mux.HandleFunc("/portal-api/organisations/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+
+		_, err := w.Write([]byte(`{"status":"error","errors":["not found"]}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithRetryPolicy(ExponentialBackoff{}),
+	)
+	require.NoError(t, err)
+
+	out, err := client.Orgs().GetOrg(ContextWithRequestID(context.Background(), "req-1"), 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, out.Meta.StatusCode)
+	assert.Equal(t, "req-1", out.Meta.RequestID)
+	assert.Equal(t, 2, out.Meta.Attempts)
+	assert.Positive(t, out.Meta.Duration)
+	assert.Equal(t, "99", out.Meta.Header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, &RateLimit{Limit: 100, Remaining: 99, Reset: time.Unix(1700000000, 0)}, out.Meta.RateLimit)
+
+	list, err := client.Orgs().ListOrgs(context.Background(), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, list.Meta.StatusCode)
+	assert.Equal(t, 1, list.Meta.Attempts)
+	assert.Nil(t, list.Meta.RateLimit)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 2)
+
+	var apiErr APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Meta().StatusCode)
+	assert.Equal(t, 1, apiErr.Meta().Attempts)
+}
+
+func TestMeta_DryRun(t *testing.T) {
+	client, err := New(WithBaseURL("http://portal.invalid"), WithToken("TOKEN"), WithDryRun())
+	require.NoError(t, err)
+
+	out, err := client.Orgs().UpdateOrg(context.Background(), 1, &UpdateOrgInput{Name: "org"})
+	require.NoError(t, err)
+
+	assert.True(t, out.DryRun)
+	assert.Equal(t, 0, out.Meta.Attempts)
+}
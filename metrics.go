@@ -0,0 +1,292 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	statusClassNetwork = "network"
+	prometheusPrefix   = "portal_client"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency
+// histogram kept by MemoryMetrics.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RequestMetrics describes a completed operation.
+type RequestMetrics struct {
+	// Operation is the operation name, for example "Orgs.CreateTeam".
+	Operation string
+	Method    string
+	// StatusCode is the status of the last attempt, or zero when no
+	// response was received.
+	StatusCode int
+	Err        error
+	// Attempts is the number of HTTP attempts, so retries are Attempts-1.
+	Attempts int
+	Duration time.Duration
+	// RequestEncoding and ResponseEncoding are the content codings of the
+	// request and response bodies, or empty when they were not compressed.
+	RequestEncoding  string
+	ResponseEncoding string
+}
+
+// StatusClass returns "2xx", "4xx", "5xx" and so on, or "network" when no
+// response was received.
+func (m RequestMetrics) StatusClass() string {
+	if m.StatusCode == 0 {
+		return statusClassNetwork
+	}
+
+	return fmt.Sprintf("%dxx", m.StatusCode/100)
+}
+
+// MetricsCollector receives one observation per operation performed by the
+// client. Implementations must be safe for concurrent use.
+type MetricsCollector interface {
+	ObserveRequest(m RequestMetrics)
+}
+
+// WithMetrics reports every operation to collector.
+func WithMetrics(collector MetricsCollector) Option {
+	return func(c *Client) {
+		c.metrics = collector
+	}
+}
+
+func (c Client) observe(m RequestMetrics) {
+	if c.metrics == nil {
+		return
+	}
+
+	if m.Operation == "" {
+		m.Operation = defaultOperationName
+	}
+
+	c.metrics.ObserveRequest(m)
+}
+
+// Histogram is a cumulative latency histogram.
+type Histogram struct {
+	// Buckets are the upper bounds in seconds. Counts[i] is the number of
+	// observations less than or equal to Buckets[i].
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+func newHistogram(buckets []float64) Histogram {
+	return Histogram{
+		Buckets: buckets,
+		Counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *Histogram) observe(seconds float64) {
+	for i, bound := range h.Buckets {
+		if seconds <= bound {
+			h.Counts[i]++
+		}
+	}
+
+	h.Count++
+	h.Sum += seconds
+}
+
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// OperationStats holds the counters for one operation.
+type OperationStats struct {
+	Requests uint64
+	Retries  uint64
+	// Errors counts failed operations by status class.
+	Errors  map[string]uint64
+	Latency Histogram
+	// CompressedRequests and CompressedResponses count compressed bodies
+	// by content coding.
+	CompressedRequests  map[string]uint64
+	CompressedResponses map[string]uint64
+}
+
+// MemoryMetrics is an in-memory MetricsCollector. It is meant for tests and
+// as the backing store of the Prometheus adapter.
+type MemoryMetrics struct {
+	mu         sync.Mutex
+	buckets    []float64
+	operations map[string]*OperationStats
+}
+
+// NewMemoryMetrics returns a collector using DefaultLatencyBuckets.
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{
+		buckets:    DefaultLatencyBuckets,
+		operations: map[string]*OperationStats{},
+	}
+}
+
+func (m *MemoryMetrics) ObserveRequest(r RequestMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.operations[r.Operation]
+	if !ok {
+		stats = &OperationStats{
+			Errors:              map[string]uint64{},
+			Latency:             newHistogram(m.buckets),
+			CompressedRequests:  map[string]uint64{},
+			CompressedResponses: map[string]uint64{},
+		}
+		m.operations[r.Operation] = stats
+	}
+
+	stats.Requests++
+
+	if r.Attempts > 1 {
+		stats.Retries += uint64(r.Attempts - 1)
+	}
+
+	if r.Err != nil {
+		stats.Errors[r.StatusClass()]++
+	}
+
+	if r.RequestEncoding != "" {
+		stats.CompressedRequests[r.RequestEncoding]++
+	}
+
+	if r.ResponseEncoding != "" {
+		stats.CompressedResponses[r.ResponseEncoding]++
+	}
+
+	stats.Latency.observe(r.Duration.Seconds())
+}
+
+// Snapshot returns a copy of the current counters keyed by operation.
+func (m *MemoryMetrics) Snapshot() map[string]OperationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]OperationStats, len(m.operations))
+
+	for op, stats := range m.operations {
+		snapshot[op] = OperationStats{
+			Requests:            stats.Requests,
+			Retries:             stats.Retries,
+			Errors:              cloneCounts(stats.Errors),
+			Latency:             stats.Latency.clone(),
+			CompressedRequests:  cloneCounts(stats.CompressedRequests),
+			CompressedResponses: cloneCounts(stats.CompressedResponses),
+		}
+	}
+
+	return snapshot
+}
+
+// WritePrometheus writes the counters in the Prometheus text exposition
+// format.
+func (m *MemoryMetrics) WritePrometheus(w io.Writer) error {
+	snapshot := m.Snapshot()
+
+	ops := make([]string, 0, len(snapshot))
+	for op := range snapshot {
+		ops = append(ops, op)
+	}
+
+	sort.Strings(ops)
+
+	bw := bufio.NewWriter(w)
+
+	writeHeader(bw, "requests_total", "counter", "Total number of portal operations.")
+	for _, op := range ops {
+		fmt.Fprintf(bw, "%s_requests_total{operation=%q} %d\n", prometheusPrefix, op, snapshot[op].Requests)
+	}
+
+	writeHeader(bw, "errors_total", "counter", "Total number of failed portal operations by status class.")
+	for _, op := range ops {
+		writeCounts(bw, "errors_total", op, "class", snapshot[op].Errors)
+	}
+
+	writeHeader(bw, "retries_total", "counter", "Total number of retried portal requests.")
+	for _, op := range ops {
+		fmt.Fprintf(bw, "%s_retries_total{operation=%q} %d\n", prometheusPrefix, op, snapshot[op].Retries)
+	}
+
+	writeHeader(bw, "compressed_requests_total", "counter", "Total number of compressed request bodies by encoding.")
+	for _, op := range ops {
+		writeCounts(bw, "compressed_requests_total", op, "encoding", snapshot[op].CompressedRequests)
+	}
+
+	writeHeader(bw, "compressed_responses_total", "counter", "Total number of compressed response bodies by encoding.")
+	for _, op := range ops {
+		writeCounts(bw, "compressed_responses_total", op, "encoding", snapshot[op].CompressedResponses)
+	}
+
+	writeHeader(bw, "request_duration_seconds", "histogram", "Latency of portal operations, retries included.")
+	for _, op := range ops {
+		h := snapshot[op].Latency
+
+		for i, bound := range h.Buckets {
+			fmt.Fprintf(
+				bw,
+				"%s_request_duration_seconds_bucket{operation=%q,le=%q} %d\n",
+				prometheusPrefix, op, strconv.FormatFloat(bound, 'g', -1, 64), h.Counts[i],
+			)
+		}
+
+		fmt.Fprintf(bw, "%s_request_duration_seconds_bucket{operation=%q,le=\"+Inf\"} %d\n", prometheusPrefix, op, h.Count)
+		fmt.Fprintf(bw, "%s_request_duration_seconds_sum{operation=%q} %v\n", prometheusPrefix, op, h.Sum)
+		fmt.Fprintf(bw, "%s_request_duration_seconds_count{operation=%q} %d\n", prometheusPrefix, op, h.Count)
+	}
+
+	return bw.Flush()
+}
+
+// writeCounts writes one sample per key of counts, labelled with label.
+func writeCounts(w io.Writer, name, op, label string, counts map[string]uint64) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s_%s{operation=%q,%s=%q} %d\n", prometheusPrefix, name, op, label, k, counts[k])
+	}
+}
+
+func cloneCounts(counts map[string]uint64) map[string]uint64 {
+	out := make(map[string]uint64, len(counts))
+	for k, n := range counts {
+		out[k] = n
+	}
+
+	return out
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s_%s %s\n", prometheusPrefix, name, help)
+	fmt.Fprintf(w, "# TYPE %s_%s %s\n", prometheusPrefix, name, kind)
+}
+
+// PrometheusHandler serves the metrics of m in the Prometheus text format so
+// they can be scraped directly.
+func PrometheusHandler(m *MemoryMetrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.WritePrometheus(w)
+	})
+}
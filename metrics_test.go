@@ -0,0 +1,89 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Collect(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var calls int32
+
+	srv.mux.HandleFunc("/portal-api/products/1", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		_, err := w.Write([]byte(`{"ID":1}`))
+		assert.NoError(t, err)
+	})
+
+	srv.mux.HandleFunc("/portal-api/products/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	metrics := NewMemoryMetrics()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMetrics(metrics),
+		WithRetryPolicy(ExponentialBackoff{Min: time.Millisecond, Max: time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Products().GetProduct(context.Background(), 1)
+	require.NoError(t, err)
+
+	_, err = client.Products().GetProduct(context.Background(), 2)
+	require.Error(t, err)
+
+	stats := metrics.Snapshot()["Products.GetProduct"]
+	assert.Equal(t, uint64(2), stats.Requests)
+	assert.Equal(t, uint64(1), stats.Retries)
+	assert.Equal(t, map[string]uint64{"4xx": 1}, stats.Errors)
+	assert.Equal(t, uint64(2), stats.Latency.Count)
+}
+
+func TestMetrics_Prometheus(t *testing.T) {
+	metrics := NewMemoryMetrics()
+	metrics.ObserveRequest(RequestMetrics{
+		Operation:  "Orgs.GetOrg",
+		Method:     http.MethodGet,
+		StatusCode: http.StatusOK,
+		Attempts:   1,
+		Duration:   20 * time.Millisecond,
+	})
+	metrics.ObserveRequest(RequestMetrics{
+		Operation: "Orgs.GetOrg",
+		Method:    http.MethodGet,
+		Err:       assert.AnError,
+		Attempts:  3,
+		Duration:  2 * time.Second,
+	})
+
+	rec := httptest.NewRecorder()
+	PrometheusHandler(metrics).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE portal_client_requests_total counter")
+	assert.Contains(t, body, `portal_client_requests_total{operation="Orgs.GetOrg"} 2`)
+	assert.Contains(t, body, `portal_client_errors_total{operation="Orgs.GetOrg",class="network"} 1`)
+	assert.Contains(t, body, `portal_client_retries_total{operation="Orgs.GetOrg"} 2`)
+	assert.Contains(t, body, `portal_client_request_duration_seconds_bucket{operation="Orgs.GetOrg",le="0.025"} 1`)
+	assert.Contains(t, body, `portal_client_request_duration_seconds_bucket{operation="Orgs.GetOrg",le="+Inf"} 2`)
+	assert.Contains(t, body, `portal_client_request_duration_seconds_count{operation="Orgs.GetOrg"} 2`)
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Request is a portal API call as seen by middlewares.
+type Request struct {
+	*http.Request
+
+	// Operation is the name of the high level call, for example
+	// "Orgs.CreateTeam". It is empty for requests built with NewRequest.
+	Operation string
+
+	// ResourceIDs holds the IDs passed to the operation, in the order of the
+	// method arguments.
+	ResourceIDs []int64
+}
+
+// Doer performs a portal API call and returns the raw response. Status codes
+// are checked after the whole middleware chain has run, so a middleware can
+// inject failures by returning a synthetic non-2xx APIResponse.
+type Doer interface {
+	Do(req *Request) (*APIResponse, error)
+}
+
+// DoerFunc adapts a function to the Doer interface.
+type DoerFunc func(req *Request) (*APIResponse, error)
+
+func (f DoerFunc) Do(req *Request) (*APIResponse, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer. It can modify the request, inspect the response
+// or return a response without calling next.
+type Middleware func(next Doer) Doer
+
+// WithMiddleware appends middlewares to the chain. The first middleware
+// added is the outermost one: it sees the request first and the response
+// last.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) {
+		middlewares := make([]Middleware, 0, len(c.middlewares)+len(mw))
+		middlewares = append(middlewares, c.middlewares...)
+
+		for _, m := range mw {
+			if m != nil {
+				middlewares = append(middlewares, m)
+			}
+		}
+
+		c.middlewares = middlewares
+	}
+}
+
+func (c Client) chain(doer Doer) Doer {
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		doer = c.middlewares[i](doer)
+	}
+
+	return doer
+}
+
+// completeResponse fills in the parts of a synthetic response that the rest
+// of the client relies on.
+func completeResponse(req *http.Request, resp *APIResponse) (*APIResponse, error) {
+	if resp == nil {
+		return nil, errors.New("middleware returned no response")
+	}
+
+	if resp.Response == nil {
+		resp.Response = &http.Response{
+			Status:     http.StatusText(http.StatusOK),
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+		}
+	}
+
+	if resp.Response.Request == nil {
+		resp.Response.Request = req
+	}
+
+	if resp.RequestID == "" && resp.Response.Header != nil {
+		resp.RequestID = resp.Response.Header.Get(headerRequestID)
+	}
+
+	return resp, nil
+}
+
+type operationKey struct{}
+
+type operation struct {
+	name string
+	ids  []int64
+}
+
+// withOperation records the high level operation on the context so the
+// request path can report it.
+func withOperation(ctx context.Context, name string, ids ...int64) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation{name: name, ids: ids})
+}
+
+// wrapError prefixes err with the operation name, or with the method and path
+// for requests that are not part of a named operation.
+func (o operation) wrapError(req *http.Request, err error) error {
+	if o.name != "" {
+		return fmt.Errorf("%v: %w", o.name, err)
+	}
+
+	return fmt.Errorf("%v %v: %w", req.Method, req.URL.Path, err)
+}
+
+func operationFromContext(ctx context.Context) operation {
+	op, _ := ctx.Value(operationKey{}).(operation)
+	return op
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_Order(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/organisations/1/teams/2", func(w http.ResponseWriter, r *http.Request) {
+		assertHeader(t, r, "X-Signed", "first,second")
+
+		_, err := w.Write([]byte(`{"ID":2,"Name":"Team"}`))
+		assert.NoError(t, err)
+	})
+
+	var calls []string
+
+	record := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return DoerFunc(func(req *Request) (*APIResponse, error) {
+				calls = append(calls, name+":"+req.Operation)
+				assert.Equal(t, []int64{1, 2}, req.ResourceIDs)
+
+				signed := req.Header.Get("X-Signed")
+				if signed != "" {
+					signed += ","
+				}
+				req.Header.Set("X-Signed", signed+name)
+
+				resp, err := next.Do(req)
+				calls = append(calls, name+":done")
+
+				return resp, err
+			})
+		}
+	}
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMiddleware(record("first")),
+		WithMiddleware(record("second")),
+	)
+	require.NoError(t, err)
+
+	resp, err := client.Orgs().GetTeam(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Data.ID)
+
+	want := []string{
+		"first:Orgs.GetTeam",
+		"second:Orgs.GetTeam",
+		"second:done",
+		"first:done",
+	}
+	assert.Equal(t, want, calls)
+}
+
+func TestMiddleware_ShortCircuit(t *testing.T) {
+	stub := func(status int, body string) Middleware {
+		return func(next Doer) Doer {
+			return DoerFunc(func(req *Request) (*APIResponse, error) {
+				return &APIResponse{
+					Response: &http.Response{StatusCode: status, Header: http.Header{}},
+					Body:     []byte(body),
+				}, nil
+			})
+		}
+	}
+
+	client, err := New(
+		WithBaseURL("http://127.0.0.1:1"),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	resp, err := client.Orgs().GetOrg(
+		context.Background(),
+		7,
+		WithMiddleware(stub(http.StatusOK, `{"ID":7,"Name":"Stubbed"}`)),
+	)
+	require.NoError(t, err)
+	assertOrg(t, &Org{ID: 7, Name: "Stubbed"}, resp.Data)
+
+	_, err = client.Orgs().GetOrg(
+		context.Background(),
+		7,
+		WithMiddleware(stub(http.StatusServiceUnavailable, `{"errors":["injected"]}`)),
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "injected")
+}
@@ -9,13 +9,13 @@ import (
 	mock "github.com/stretchr/testify/mock"
 )
 
-// Catalogs is an autogenerated mock type for the Catalogs type
-type Catalogs struct {
+// Catalogues is an autogenerated mock type for the Catalogues type
+type Catalogues struct {
 	mock.Mock
 }
 
-// CreateCatalog provides a mock function with given fields: ctx, input, opts
-func (_m *Catalogs) CreateCatalog(ctx context.Context, input *portal.CatalogInput, opts ...portal.Option) (*portal.CatalogOutput, error) {
+// CreateCatalogue provides a mock function with given fields: ctx, input, opts
+func (_m *Catalogues) CreateCatalogue(ctx context.Context, input *portal.CatalogueInput, opts ...portal.Option) (*portal.CatalogueOutput, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -25,20 +25,20 @@ func (_m *Catalogs) CreateCatalog(ctx context.Context, input *portal.CatalogInpu
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *portal.CatalogOutput
+	var r0 *portal.CatalogueOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *portal.CatalogInput, ...portal.Option) (*portal.CatalogOutput, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *portal.CatalogueInput, ...portal.Option) (*portal.CatalogueOutput, error)); ok {
 		return rf(ctx, input, opts...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *portal.CatalogInput, ...portal.Option) *portal.CatalogOutput); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *portal.CatalogueInput, ...portal.Option) *portal.CatalogueOutput); ok {
 		r0 = rf(ctx, input, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*portal.CatalogOutput)
+			r0 = ret.Get(0).(*portal.CatalogueOutput)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *portal.CatalogInput, ...portal.Option) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *portal.CatalogueInput, ...portal.Option) error); ok {
 		r1 = rf(ctx, input, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -47,8 +47,8 @@ func (_m *Catalogs) CreateCatalog(ctx context.Context, input *portal.CatalogInpu
 	return r0, r1
 }
 
-// GetCatalog provides a mock function with given fields: ctx, id, opts
-func (_m *Catalogs) GetCatalog(ctx context.Context, id int64, opts ...portal.Option) (*portal.CatalogOutput, error) {
+// DeleteCatalogue provides a mock function with given fields: ctx, id, opts
+func (_m *Catalogues) DeleteCatalogue(ctx context.Context, id int64, opts ...portal.Option) (*portal.CatalogueOutput, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -58,16 +58,16 @@ func (_m *Catalogs) GetCatalog(ctx context.Context, id int64, opts ...portal.Opt
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *portal.CatalogOutput
+	var r0 *portal.CatalogueOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, ...portal.Option) (*portal.CatalogOutput, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64, ...portal.Option) (*portal.CatalogueOutput, error)); ok {
 		return rf(ctx, id, opts...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, ...portal.Option) *portal.CatalogOutput); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64, ...portal.Option) *portal.CatalogueOutput); ok {
 		r0 = rf(ctx, id, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*portal.CatalogOutput)
+			r0 = ret.Get(0).(*portal.CatalogueOutput)
 		}
 	}
 
@@ -80,8 +80,41 @@ func (_m *Catalogs) GetCatalog(ctx context.Context, id int64, opts ...portal.Opt
 	return r0, r1
 }
 
-// ListCatalogs provides a mock function with given fields: ctx, options, opts
-func (_m *Catalogs) ListCatalogs(ctx context.Context, options *portal.ListCatalogsInput, opts ...portal.Option) (*portal.ListCatalogsOutput, error) {
+// GetCatalogue provides a mock function with given fields: ctx, id, opts
+func (_m *Catalogues) GetCatalogue(ctx context.Context, id int64, opts ...portal.Option) (*portal.CatalogueOutput, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, id)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *portal.CatalogueOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, ...portal.Option) (*portal.CatalogueOutput, error)); ok {
+		return rf(ctx, id, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, ...portal.Option) *portal.CatalogueOutput); ok {
+		r0 = rf(ctx, id, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*portal.CatalogueOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, ...portal.Option) error); ok {
+		r1 = rf(ctx, id, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCatalogues provides a mock function with given fields: ctx, options, opts
+func (_m *Catalogues) ListCatalogues(ctx context.Context, options *portal.ListCataloguesInput, opts ...portal.Option) (*portal.ListCataloguesOutput, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -91,20 +124,20 @@ func (_m *Catalogs) ListCatalogs(ctx context.Context, options *portal.ListCatalo
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *portal.ListCatalogsOutput
+	var r0 *portal.ListCataloguesOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *portal.ListCatalogsInput, ...portal.Option) (*portal.ListCatalogsOutput, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *portal.ListCataloguesInput, ...portal.Option) (*portal.ListCataloguesOutput, error)); ok {
 		return rf(ctx, options, opts...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *portal.ListCatalogsInput, ...portal.Option) *portal.ListCatalogsOutput); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *portal.ListCataloguesInput, ...portal.Option) *portal.ListCataloguesOutput); ok {
 		r0 = rf(ctx, options, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*portal.ListCatalogsOutput)
+			r0 = ret.Get(0).(*portal.ListCataloguesOutput)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *portal.ListCatalogsInput, ...portal.Option) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *portal.ListCataloguesInput, ...portal.Option) error); ok {
 		r1 = rf(ctx, options, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -113,8 +146,8 @@ func (_m *Catalogs) ListCatalogs(ctx context.Context, options *portal.ListCatalo
 	return r0, r1
 }
 
-// UpdateCatalog provides a mock function with given fields: ctx, id, input, opts
-func (_m *Catalogs) UpdateCatalog(ctx context.Context, id int64, input *portal.CatalogInput, opts ...portal.Option) (*portal.CatalogOutput, error) {
+// UpdateCatalogue provides a mock function with given fields: ctx, id, input, opts
+func (_m *Catalogues) UpdateCatalogue(ctx context.Context, id int64, input *portal.CatalogueInput, opts ...portal.Option) (*portal.CatalogueOutput, error) {
 	_va := make([]interface{}, len(opts))
 	for _i := range opts {
 		_va[_i] = opts[_i]
@@ -124,20 +157,20 @@ func (_m *Catalogs) UpdateCatalog(ctx context.Context, id int64, input *portal.C
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
-	var r0 *portal.CatalogOutput
+	var r0 *portal.CatalogueOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, *portal.CatalogInput, ...portal.Option) (*portal.CatalogOutput, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *portal.CatalogueInput, ...portal.Option) (*portal.CatalogueOutput, error)); ok {
 		return rf(ctx, id, input, opts...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, *portal.CatalogInput, ...portal.Option) *portal.CatalogOutput); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *portal.CatalogueInput, ...portal.Option) *portal.CatalogueOutput); ok {
 		r0 = rf(ctx, id, input, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*portal.CatalogOutput)
+			r0 = ret.Get(0).(*portal.CatalogueOutput)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, *portal.CatalogInput, ...portal.Option) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, int64, *portal.CatalogueInput, ...portal.Option) error); ok {
 		r1 = rf(ctx, id, input, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -146,13 +179,13 @@ func (_m *Catalogs) UpdateCatalog(ctx context.Context, id int64, input *portal.C
 	return r0, r1
 }
 
-// NewCatalogs creates a new instance of Catalogs. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// NewCatalogues creates a new instance of Catalogues. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
-func NewCatalogs(t interface {
+func NewCatalogues(t interface {
 	mock.TestingT
 	Cleanup(func())
-}) *Catalogs {
-	mock := &Catalogs{}
+}) *Catalogues {
+	mock := &Catalogues{}
 	mock.Mock.Test(t)
 
 	t.Cleanup(func() { mock.AssertExpectations(t) })
@@ -0,0 +1,102 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// DialFunc opens the connections used by the client's transport.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// WithRootCAs sets the certificate authorities used to verify the portal's
+// certificate instead of the system pool.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *Client) {
+		c.rootCAs = pool
+	}
+}
+
+// WithRootCAFile reads a PEM bundle of certificate authorities used to verify
+// the portal's certificate.
+func WithRootCAFile(path string) Option {
+	return func(c *Client) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			c.optionErr = fmt.Errorf("reading CA file: %w", err)
+			return
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			c.optionErr = fmt.Errorf("no certificates found in CA file %v", path)
+			return
+		}
+
+		c.rootCAs = pool
+	}
+}
+
+// WithClientCertificate presents cert to the portal for mutual TLS.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(c *Client) {
+		c.clientCerts = append(c.clientCerts[:len(c.clientCerts):len(c.clientCerts)], cert)
+	}
+}
+
+// WithClientCertificateFile loads a PEM encoded certificate and key pair and
+// presents it to the portal for mutual TLS.
+func WithClientCertificateFile(certFile, keyFile string) Option {
+	return func(c *Client) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			c.optionErr = fmt.Errorf("loading client certificate: %w", err)
+			return
+		}
+
+		WithClientCertificate(cert)(c)
+	}
+}
+
+// WithProxy sets the function that picks the proxy for each request, for
+// example http.ProxyFromEnvironment or http.ProxyURL(u). By default no proxy
+// is used.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) Option {
+	return func(c *Client) {
+		c.proxy = proxy
+	}
+}
+
+// WithDialer replaces the dialer of the client's transport. The connect
+// timeout still applies.
+func WithDialer(dial DialFunc) Option {
+	return func(c *Client) {
+		c.dial = dial
+	}
+}
+
+// WithUnixSocket sends every request over the Unix socket at path, whatever
+// host the base URL names.
+func WithUnixSocket(path string) Option {
+	return WithDialer(func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	})
+}
+
+func (c Client) tlsConfig() *tls.Config {
+	return &tls.Config{
+		//nolint:gosec
+		InsecureSkipVerify: c.insecure,
+		RootCAs:            c.rootCAs,
+		Certificates:       c.clientCerts,
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func orgHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"ID":1,"Name":"Default Org"}`))
+		assert.NoError(t, err)
+	}
+}
+
+func TestNetwork_RootCAs(t *testing.T) {
+	srv := httptest.NewTLSServer(orgHandler(t))
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	client, err := New(WithBaseURL(srv.URL), WithToken("TOKEN"), WithRootCAs(pool))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+
+	untrusted, err := New(WithBaseURL(srv.URL), WithToken("TOKEN"), WithMaxRetries(0))
+	require.NoError(t, err)
+	defer untrusted.Close()
+
+	_, err = untrusted.Orgs().GetOrg(context.Background(), 1)
+	require.Error(t, err)
+}
+
+func TestNetwork_RootCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(orgHandler(t))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	client, err := New(WithBaseURL(srv.URL), WithToken("TOKEN"), WithRootCAFile(path))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+
+	_, err = New(WithToken("TOKEN"), WithRootCAFile(filepath.Join(t.TempDir(), "missing.pem")))
+	assert.ErrorContains(t, err, "reading CA file")
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1, WithRootCAFile(filepath.Join(t.TempDir(), "missing.pem")))
+	assert.ErrorContains(t, err, "reading CA file")
+}
+
+func TestNetwork_ClientCertificate(t *testing.T) {
+	var presented int32
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			atomic.AddInt32(&presented, 1)
+		}
+
+		orgHandler(t)(w, r)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	// The test server's own certificate doubles as the client certificate.
+	cert := srv.TLS.Certificates[0]
+
+	client, err := New(
+		WithBaseURL(srv.URL),
+		WithToken("TOKEN"),
+		WithRootCAs(pool),
+		WithClientCertificate(cert),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&presented))
+
+	_, err = New(WithToken("TOKEN"), WithClientCertificateFile("missing.crt", "missing.key"))
+	assert.ErrorContains(t, err, "loading client certificate")
+}
+
+func TestNetwork_Proxy(t *testing.T) {
+	var proxied int32
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		assert.Equal(t, "http://portal.invalid/portal-api/organisations/1", r.URL.String())
+
+		orgHandler(t)(w, r)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	client, err := New(
+		WithBaseURL("http://portal.invalid"),
+		WithToken("TOKEN"),
+		WithProxy(http.ProxyURL(proxyURL)),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&proxied))
+}
+
+func TestNetwork_UnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "portal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "portal.sock")
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(orgHandler(t))
+	srv.Listener = listener
+	srv.Start()
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL("http://portal.sidecar"),
+		WithToken("TOKEN"),
+		WithUnixSocket(socket),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	org, err := client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Default Org", org.Data.Name)
+}
@@ -37,16 +37,18 @@ type orgs struct {
 }
 
 func (p orgs) CreateOrg(ctx context.Context, input *CreateOrgInput, opts ...Option) (*CreateOrgOutput, error) {
+	ctx = withOperation(ctx, "Orgs.CreateOrg")
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := input.validate(); err != nil {
+	if err := p.client.validateInput(input, opts...); err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPost(ctx, pathOrgs, bytes.NewReader(payload), nil)
+	resp, err := p.client.doCreate(ctx, pathOrgs, payload, p.findOrgByName(input), opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -58,12 +60,16 @@ func (p orgs) CreateOrg(ctx context.Context, input *CreateOrgInput, opts ...Opti
 	}
 
 	return &CreateOrgOutput{
-		Data: &org,
+		Data:   &org,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p orgs) GetOrg(ctx context.Context, id int64, opts ...Option) (*GetOrgOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathOrg, id), nil)
+	ctx = withOperation(ctx, "Orgs.GetOrg", id)
+
+	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathOrg, id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -75,33 +81,33 @@ func (p orgs) GetOrg(ctx context.Context, id int64, opts ...Option) (*GetOrgOutp
 
 	return &GetOrgOutput{
 		Data: &org,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p orgs) ListOrgs(ctx context.Context, options *ListOrgsInput, opts ...Option) (*ListOrgsOutput, error) {
-	resp, err := p.client.doGet(ctx, pathOrgs, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var orgs []Org
+	ctx = withOperation(ctx, "Orgs.ListOrgs")
 
-	if err := resp.Unmarshal(&orgs); err != nil {
+	orgs, resp, err := listItems[Org](ctx, p.client, pathOrgs, nil, opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListOrgsOutput{
 		Data: orgs,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p orgs) UpdateOrg(ctx context.Context, id int64, input *UpdateOrgInput, opts ...Option) (*UpdateOrgOutput, error) {
+	ctx = withOperation(ctx, "Orgs.UpdateOrg", id)
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathOrg, id), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathOrg, id), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -113,30 +119,39 @@ func (p orgs) UpdateOrg(ctx context.Context, id int64, input *UpdateOrgInput, op
 	}
 
 	return &UpdateOrgOutput{
-		Data: &org,
+		Data:   &org,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p orgs) DeleteOrg(ctx context.Context, id int64, opts ...Option) (*DeleteOrgOutput, error) {
-	_, err := p.client.doDelete(ctx, fmt.Sprintf(pathOrg, id), nil, nil)
+	ctx = withOperation(ctx, "Orgs.DeleteOrg", id)
+
+	resp, err := p.client.doDelete(ctx, fmt.Sprintf(pathOrg, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &GetOrgOutput{}, nil
+	return &GetOrgOutput{
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
+	}, nil
 }
 
 func (p orgs) CreateTeam(ctx context.Context, orgID int64, input *TeamInput, opts ...Option) (*TeamOutput, error) {
+	ctx = withOperation(ctx, "Orgs.CreateTeam", orgID)
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := input.validate(); err != nil {
+	if err := p.client.validateInput(input, opts...); err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPost(ctx, fmt.Sprintf(pathOrgTeams, orgID), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPost(ctx, fmt.Sprintf(pathOrgTeams, orgID), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -148,12 +163,16 @@ func (p orgs) CreateTeam(ctx context.Context, orgID int64, input *TeamInput, opt
 	}
 
 	return &TeamOutput{
-		Data: &org,
+		Data:   &org,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p orgs) GetTeam(ctx context.Context, orgID, teamID int64, opts ...Option) (*TeamOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathOrgTeam, orgID, teamID), nil)
+	ctx = withOperation(ctx, "Orgs.GetTeam", orgID, teamID)
+
+	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathOrgTeam, orgID, teamID), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -165,33 +184,33 @@ func (p orgs) GetTeam(ctx context.Context, orgID, teamID int64, opts ...Option)
 
 	return &TeamOutput{
 		Data: &org,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p orgs) ListTeams(ctx context.Context, orgID int64, options *ListTeamsInput, opts ...Option) (*ListTeamsOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathOrgTeams, orgID), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var orgs []Team
+	ctx = withOperation(ctx, "Orgs.ListTeams", orgID)
 
-	if err := resp.Unmarshal(&orgs); err != nil {
+	orgs, resp, err := listItems[Team](ctx, p.client, fmt.Sprintf(pathOrgTeams, orgID), nil, opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListTeamsOutput{
 		Data: orgs,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p orgs) UpdateTeam(ctx context.Context, orgID, teamID int64, input *TeamInput, opts ...Option) (*TeamOutput, error) {
+	ctx = withOperation(ctx, "Orgs.UpdateTeam", orgID, teamID)
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathOrgTeam, orgID, teamID), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathOrgTeam, orgID, teamID), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -203,17 +222,24 @@ func (p orgs) UpdateTeam(ctx context.Context, orgID, teamID int64, input *TeamIn
 	}
 
 	return &TeamOutput{
-		Data: &org,
+		Data:   &org,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p orgs) DeleteTeam(ctx context.Context, orgID, teamID int64, opts ...Option) (*TeamOutput, error) {
-	_, err := p.client.doDelete(ctx, fmt.Sprintf(pathOrgTeam, orgID, teamID), nil, nil)
+	ctx = withOperation(ctx, "Orgs.DeleteTeam", orgID, teamID)
+
+	resp, err := p.client.doDelete(ctx, fmt.Sprintf(pathOrgTeam, orgID, teamID), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &TeamOutput{}, nil
+	return &TeamOutput{
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
+	}, nil
 }
 
 type OrgInput struct {
@@ -237,10 +263,13 @@ type (
 
 type ListOrgsOutput struct {
 	Data []Org
+	Meta Meta
 }
 
 type OrgOutput struct {
-	Data *Org
+	Data   *Org
+	DryRun bool
+	Meta   Meta
 }
 
 type (
@@ -308,11 +337,14 @@ type Team struct {
 }
 
 type TeamOutput struct {
-	Data *Team
+	Data   *Team
+	DryRun bool
+	Meta   Meta
 }
 
 type ListTeamsOutput struct {
 	Data []Team
+	Meta Meta
 }
 
 type (
@@ -29,12 +29,14 @@ type pages struct {
 }
 
 func (p pages) CreatePage(ctx context.Context, input *CreatePageInput, opts ...Option) (*CreatePageOutput, error) {
+	ctx = withOperation(ctx, "Pages.CreatePage")
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPost(ctx, pathPages, bytes.NewReader(payload), nil)
+	resp, err := p.client.doCreate(ctx, pathPages, payload, p.findPageByPath(input), opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -46,12 +48,16 @@ func (p pages) CreatePage(ctx context.Context, input *CreatePageInput, opts ...O
 	}
 
 	return &CreatePageOutput{
-		Data: &page,
+		Data:   &page,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p pages) GetPage(ctx context.Context, id int64, opts ...Option) (*GetPageOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathPage, id), nil)
+	ctx = withOperation(ctx, "Pages.GetPage", id)
+
+	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathPage, id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -63,33 +69,33 @@ func (p pages) GetPage(ctx context.Context, id int64, opts ...Option) (*GetPageO
 
 	return &GetPageOutput{
 		Data: &page,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p pages) ListPages(ctx context.Context, options *ListPagesInput, opts ...Option) (*ListPagesOutput, error) {
-	resp, err := p.client.doGet(ctx, pathPages, nil)
-	if err != nil {
-		return nil, err
-	}
+	ctx = withOperation(ctx, "Pages.ListPages")
 
-	var pages []Page
-
-	if err := resp.Unmarshal(&pages); err != nil {
+	pages, resp, err := listItems[Page](ctx, p.client, pathPages, nil, opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListPagesOutput{
 		Pages: pages,
+		Meta:  resp.Meta(),
 	}, nil
 }
 
 func (p pages) UpdatePage(ctx context.Context, id int64, input *UpdatePageInput, opts ...Option) (*UpdatePageOutput, error) {
+	ctx = withOperation(ctx, "Pages.UpdatePage", id)
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathPage, id), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathPage, id), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -101,17 +107,24 @@ func (p pages) UpdatePage(ctx context.Context, id int64, input *UpdatePageInput,
 	}
 
 	return &UpdatePageOutput{
-		Data: &page,
+		Data:   &page,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p pages) DeletePage(ctx context.Context, id int64, opts ...Option) (*PageOutput, error) {
-	_, err := p.client.doDelete(ctx, fmt.Sprintf(pathPage, id), nil, nil)
+	ctx = withOperation(ctx, "Pages.DeletePage", id)
+
+	resp, err := p.client.doDelete(ctx, fmt.Sprintf(pathPage, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &PageOutput{}, nil
+	return &PageOutput{
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
+	}, nil
 }
 
 type PageInput struct {
@@ -131,6 +144,7 @@ type ListPagesInput struct{}
 
 type ListPagesOutput struct {
 	Pages []Page
+	Meta  Meta
 }
 
 type Page struct {
@@ -146,7 +160,9 @@ type Page struct {
 }
 
 type PageOutput struct {
-	Data *Page
+	Data   *Page
+	DryRun bool
+	Meta   Meta
 }
 
 type UpdatePageOutput = PageOutput
@@ -29,12 +29,14 @@ type plans struct {
 
 // CreatePlan ...
 func (p plans) CreatePlan(ctx context.Context, input *CreatePlanInput, opts ...Option) (*CreatePlanOutput, error) {
+	ctx = withOperation(ctx, "Plans.CreatePlan")
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPost(ctx, pathPlans, bytes.NewReader(payload), nil)
+	resp, err := p.client.doPost(ctx, pathPlans, bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -46,13 +48,17 @@ func (p plans) CreatePlan(ctx context.Context, input *CreatePlanInput, opts ...O
 	}
 
 	return &CreatePlanOutput{
-		Data: &plan,
+		Data:   &plan,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 // GetPlan ...
 func (p plans) GetPlan(ctx context.Context, id int64, opts ...Option) (*GetPlanOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathPlan, id), nil)
+	ctx = withOperation(ctx, "Plans.GetPlan", id)
+
+	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathPlan, id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -64,35 +70,35 @@ func (p plans) GetPlan(ctx context.Context, id int64, opts ...Option) (*GetPlanO
 
 	return &GetPlanOutput{
 		Data: &plan,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 // ListPlans ...
 func (p plans) ListPlans(ctx context.Context, options *ListPlansInput, opts ...Option) (*ListPlansOutput, error) {
-	resp, err := p.client.doGet(ctx, pathPlans, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var plans []Plan
+	ctx = withOperation(ctx, "Plans.ListPlans")
 
-	if err := resp.Unmarshal(&plans); err != nil {
+	plans, resp, err := listItems[Plan](ctx, p.client, pathPlans, nil, opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListPlansOutput{
 		Data: plans,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 // UpdatePlan ...
 func (p plans) UpdatePlan(ctx context.Context, id int64, input *UpdatePlanInput, opts ...Option) (*UpdatePlanOutput, error) {
+	ctx = withOperation(ctx, "Plans.UpdatePlan", id)
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathPlan, id), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathPlan, id), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +110,9 @@ func (p plans) UpdatePlan(ctx context.Context, id int64, input *UpdatePlanInput,
 	}
 
 	return &UpdatePlanOutput{
-		Data: &plan,
+		Data:   &plan,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
@@ -130,6 +138,7 @@ type ListPlansInput struct{}
 
 type ListPlansOutput struct {
 	Data []Plan
+	Meta Meta
 }
 
 type Plan struct {
@@ -147,7 +156,9 @@ type Plan struct {
 }
 
 type PlanOutput struct {
-	Data *Plan
+	Data   *Plan
+	DryRun bool
+	Meta   Meta
 }
 
 type UpdatePlanOutput = PlanOutput
@@ -6,15 +6,19 @@ package portal
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -22,8 +26,8 @@ const (
 	headerAccept          = "Accept"
 	headerContentType     = "Content-Type"
 	defaultBaseURL        = "http://localhost:3001"
-	defaultConnectTimeout = 60000
-	defaultReadTimeout    = 60000
+	defaultConnectTimeout = 60 * time.Second
+	defaultReadTimeout    = 60 * time.Second
 	defaultUserAgent      = "ua"
 )
 
@@ -41,6 +45,10 @@ func WithDialTimeout(d time.Duration) Option {
 	}
 }
 
+// WithReadTimeout limits how long each attempt may take, reading the
+// response included. For list responses that are streamed, it only limits
+// the wait for the response headers, so that slow item callbacks are not
+// cut off; the context bounds the rest.
 func WithReadTimeout(d time.Duration) Option {
 	return func(o *Client) {
 		o.readTimeout = d
@@ -53,15 +61,23 @@ func WithHTTPClient(c HTTPClient) Option {
 	}
 }
 
+// WithBaseURL sets the portal's base URL. It replaces the endpoints set by
+// WithBaseURLs, turning failover off.
 func WithBaseURL(url string) Option {
 	return func(o *Client) {
 		o.baseURL = url
+		o.failover = nil
 	}
 }
 
+// WithToken sets a static Authorization token. Use WithTokenSource for
+// tokens that rotate.
 func WithToken(value string) Option {
 	return func(o *Client) {
-		o.token = value
+		o.tokenSource = nil
+		if value != "" {
+			o.tokenSource = StaticToken(value)
+		}
 	}
 }
 
@@ -83,18 +99,41 @@ func WithUserAgent(ua string) Option {
 	}
 }
 
+// WithDebug logs redacted request and response dumps at debug level to the
+// logger set with WithLogger. It has no effect without one; the client never
+// writes to the global logger.
 func WithDebug(debug bool) Option {
 	return func(o *Client) {
 		o.debug = debug
 	}
 }
 
+// WithMaxRetries sets how many times a request is retried after the first
+// attempt, so a request is sent at most n+1 times. The default is 3 retries,
+// or 4 attempts.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithHeaders adds headers to every request. Headers given per call are
+// merged with the ones set on the client.
 func WithHeaders(h map[string]string) Option {
 	return func(c *Client) {
-		headers := http.Header{}
+		headers := c.headers.Clone()
+		if headers == nil {
+			headers = http.Header{}
+		}
 
 		for k, v := range h {
-			headers.Add(k, v)
+			headers.Set(k, v)
 		}
 
 		c.headers = headers
@@ -106,15 +145,62 @@ type Client struct {
 	connectTimeout  time.Duration
 	readTimeout     time.Duration
 	userAgent       string
-	token           string
+	tokenSource     TokenSource
 	debug           bool
 	insecure        bool
 	baseURL         string
 	maxRetries      int
 	minRetryBackoff time.Duration
+	retryPolicy     RetryPolicy
+	middlewares     []Middleware
+	logger          *slog.Logger
+	tracerProvider  trace.TracerProvider
+	metrics         MetricsCollector
+	rateLimiter     *rateLimiter
+	inflight        chan struct{}
+	breakers        *breakerRegistry
+	cache           Cache
+	flights         *flightGroup
+	maxResponseSize int64
+	itemCallbacks   map[reflect.Type]interface{}
 	skipValidation  bool
 	headers         http.Header
 
+	acceptEncodings           []string
+	requestEncoding           string
+	requestCompressionMinSize int
+
+	requestIDGenerator func() string
+	noRequestID        bool
+
+	dryRun           *dryRunLog
+	idempotentCreate bool
+
+	failover         *failover
+	failback         time.Duration
+	onEndpointChange func(from, to string)
+
+	portalVersion string
+	versionHeader string
+	minVersions   map[Capability]string
+	server        *serverInfo
+
+	transport             *http.Transport
+	maxIdleConns          int
+	maxIdleConnsPerHost   int
+	idleConnTimeout       time.Duration
+	keepAlive             time.Duration
+	disableHTTP2          bool
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	rootCAs               *x509.CertPool
+	clientCerts           []tls.Certificate
+	proxy                 func(*http.Request) (*url.URL, error)
+	dial                  DialFunc
+
+	// optionErr records the first error raised while applying options.
+	optionErr error
+
 	pages      Pages
 	providers  Providers
 	plans      Plans
@@ -222,7 +308,11 @@ func New(opts ...Option) (*Client, error) {
 }
 
 func (c Client) validate() error {
-	if c.token == "" {
+	if c.optionErr != nil {
+		return c.optionErr
+	}
+
+	if c.tokenSource == nil {
 		return fmt.Errorf("token is required")
 	}
 
@@ -231,16 +321,21 @@ func (c Client) validate() error {
 
 func newClient(opts ...Option) (*Client, error) {
 	client := &Client{
-		baseURL:        defaultBaseURL,
-		connectTimeout: defaultConnectTimeout,
-	}
-
-	if client.maxRetries == 0 {
-		client.maxRetries = 3
-	}
-
-	if client.minRetryBackoff == 0 {
-		client.minRetryBackoff = 100 * time.Millisecond
+		baseURL:         defaultBaseURL,
+		connectTimeout:  defaultConnectTimeout,
+		readTimeout:     defaultReadTimeout,
+		maxRetries:      defaultMaxRetries,
+		minRetryBackoff: defaultMinRetryBackoff,
+		acceptEncodings: []string{EncodingGzip},
+		failback:        defaultFailback,
+		server:          &serverInfo{},
+
+		maxIdleConns:          defaultMaxIdleConns,
+		maxIdleConnsPerHost:   defaultMaxIdleConnsPerHost,
+		idleConnTimeout:       defaultIdleConnTimeout,
+		keepAlive:             defaultKeepAlive,
+		tlsHandshakeTimeout:   defaultTLSHandshakeTimeout,
+		responseHeaderTimeout: defaultResponseHeaderTimeout,
 	}
 
 	client.Apply(opts...)
@@ -249,6 +344,8 @@ func newClient(opts ...Option) (*Client, error) {
 		return nil, err
 	}
 
+	client.transport = client.newTransport()
+
 	client.providers = &providers{client: client}
 	client.plans = &plans{client: client}
 	client.users = &users{client: client}
@@ -271,6 +368,9 @@ func (c Client) NewRequest(
 	params url.Values, opts ...Option,
 ) (*http.Request, error) {
 	client := c.copy(opts...)
+	if client.optionErr != nil {
+		return nil, client.optionErr
+	}
 
 	newPath, err := url.JoinPath(client.baseURL, path)
 	if err != nil {
@@ -279,7 +379,10 @@ func (c Client) NewRequest(
 
 	var newBody io.Reader = http.NoBody
 	if body != nil {
-		newBody = body
+		newBody, err = rewindableBody(body)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, newPath, newBody)
@@ -287,16 +390,29 @@ func (c Client) NewRequest(
 		return nil, err
 	}
 
-	req.Header.Add(headerAuthorization, client.token)
+	token, err := client.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add(headerAuthorization, token)
 	req.Header.Add(headerAccept, "application/json")
 
+	if id := client.requestID(ctx); id != "" {
+		req.Header.Set(headerRequestID, id)
+	}
+
+	if len(client.acceptEncodings) > 0 {
+		req.Header.Set(headerAcceptEncoding, strings.Join(client.acceptEncodings, ", "))
+	}
+
 	if body != nil {
 		req.Header.Add(headerContentType, "application/json")
 	}
 
 	req.Header.Set("User-Agent", defaultUserAgent)
-	if c.userAgent != "" {
-		req.Header.Set("User-Agent", c.userAgent)
+	if client.userAgent != "" {
+		req.Header.Set("User-Agent", client.userAgent)
 	}
 
 	for k, v := range client.headers {
@@ -327,7 +443,7 @@ func (c Client) newDeleteRequest(
 	params url.Values,
 	opts ...Option,
 ) (*http.Request, error) {
-	return c.NewRequest(ctx, http.MethodDelete, path, body, params)
+	return c.NewRequest(ctx, http.MethodDelete, path, body, params, opts...)
 }
 
 func (c Client) doGet(ctx context.Context, path string, params url.Values, opts ...Option) (*APIResponse, error) {
@@ -336,7 +452,7 @@ func (c Client) doGet(ctx context.Context, path string, params url.Values, opts
 		return nil, err
 	}
 
-	resp, err := c.performRequest(ctx, req)
+	resp, err := c.performRequest(ctx, req, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -350,7 +466,7 @@ func (c Client) doPost(ctx context.Context, path string, body io.Reader, params
 		return nil, err
 	}
 
-	resp, err := c.performRequest(ctx, req)
+	resp, err := c.performRequest(ctx, req, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -364,7 +480,7 @@ func (c Client) doDelete(ctx context.Context, path string, body io.Reader, param
 		return nil, err
 	}
 
-	resp, err := c.performRequest(ctx, req)
+	resp, err := c.performRequest(ctx, req, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -386,6 +502,20 @@ func (c Client) doPut(ctx context.Context, path string, body io.Reader, params u
 	return resp, nil
 }
 
+type validator interface {
+	validate() error
+}
+
+// validateInput validates input unless validation is skipped for the client
+// or for the current call.
+func (c Client) validateInput(input validator, opts ...Option) error {
+	if c.copy(opts...).skipValidation {
+		return nil
+	}
+
+	return input.validate()
+}
+
 func (c Client) copy(opts ...Option) Client {
 	newClient := c
 
@@ -394,90 +524,177 @@ func (c Client) copy(opts ...Option) Client {
 	return newClient
 }
 
+// performRequest sends req through the middleware chain and the retry loop
+// and checks the response status. It runs on the caller's goroutine: when ctx
+// is cancelled the in-flight HTTP call and any backoff sleep are aborted and
+// ctx.Err() is returned, wrapped with the operation name.
 func (c Client) performRequest(ctx context.Context, req *http.Request, opts ...Option) (*APIResponse, error) {
+	op := operationFromContext(ctx)
+
 	if err := ctx.Err(); err != nil {
-		return nil, err
+		return nil, op.wrapError(req, err)
 	}
 
 	newClient := c.copy(opts...)
-
-	var httpClient HTTPClient = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				//nolint:gosec
-				InsecureSkipVerify: newClient.insecure,
-			},
-			DialContext: (&net.Dialer{
-				Timeout: newClient.connectTimeout * time.Millisecond,
-			}).DialContext,
-		},
-		Timeout: newClient.readTimeout * time.Millisecond,
+	if newClient.optionErr != nil {
+		return nil, op.wrapError(req, newClient.optionErr)
 	}
 
+	var httpClient HTTPClient = newClient.defaultHTTPClient()
 	if newClient.httpClient != nil {
 		httpClient = newClient.httpClient
 	}
 
 	var (
-		attempt  int
-		httpResp *http.Response
-		err      error
-		respC    = make(chan APIResponse)
-		errC     = make(chan error)
+		start    = time.Now()
+		attempts int
 	)
 
-	backoff := c.minRetryBackoff
+	doer := newClient.chain(DoerFunc(func(r *Request) (*APIResponse, error) {
+		if newClient.dryRunApplies(r.Request) {
+			return newClient.planChange(r.Request)
+		}
 
-	go func() {
-		for attempt = 0; attempt < c.maxRetries; attempt++ {
-			httpResp, err = httpClient.Do(req)
-			if err != nil {
-				retry := shouldRetry(err)
-				if retry && attempt < c.maxRetries-1 {
-					time.Sleep(backoff)
-					backoff *= 2
-					continue
-				}
+		resp, n, err := newClient.coalesce(r.Request, func(req *http.Request) (*APIResponse, int, error) {
+			return newClient.send(httpClient, req)
+		})
+		attempts = n
 
-				errC <- err
-				return
-			}
+		return resp, err
+	}))
 
-			break
-		}
+	spanCtx, span := newClient.startOperationSpan(withDialTimeout(req.Context(), newClient.connectTimeout), req)
+	req = req.WithContext(spanCtx)
 
-		defer httpResp.Body.Close()
+	resp, err := doer.Do(&Request{
+		Request:     req,
+		Operation:   op.name,
+		ResourceIDs: op.ids,
+	})
+	if err == nil {
+		resp, err = completeResponse(req, resp)
+	}
 
-		body, err := io.ReadAll(httpResp.Body)
-		if err != nil {
-			errC <- err
-			return
-		}
+	if err == nil {
+		err = checkError(resp)
+	}
 
-		r := &APIResponse{
-			Body:     body,
-			Response: httpResp,
-		}
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		err = op.wrapError(req, ctxErr)
+	}
+
+	var httpResp *http.Response
+	if resp != nil {
+		httpResp = resp.Response
+	}
 
-		if err := checkError(r); err != nil {
-			errC <- err
-			return
+	newClient.observeVersion(httpResp)
+
+	endSpan(span, httpResp, err)
+
+	m := RequestMetrics{
+		Operation: op.name,
+		Method:    req.Method,
+		Err:       err,
+		Attempts:  attempts,
+		Duration:  time.Since(start),
+	}
+
+	if httpResp != nil {
+		m.StatusCode = httpResp.StatusCode
+	}
+
+	if resp != nil {
+		m.RequestEncoding = resp.requestEncoding
+		m.ResponseEncoding = resp.responseEncoding
+
+		resp.attempts = attempts
+		resp.duration = m.Duration
+	}
+
+	newClient.observe(m)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// send performs req with retries at the end of the middleware chain and
+// reads the response body.
+func (c Client) send(httpClient HTTPClient, req *http.Request) (*APIResponse, int, error) {
+	ctx := req.Context()
+
+	defer c.invalidateCache(req)
+
+	entry := c.prepareConditional(req)
+
+	c.dumpRequest(ctx, req)
+
+	wireReq, requestEncoding, err := c.compressRequest(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	httpResp, attempts, err := c.doWithRetry(ctx, httpClient, wireReq)
+	if err != nil {
+		return nil, attempts, err
+	}
+
+	defer httpResp.Body.Close()
+
+	resp, err := c.readResponse(req, httpResp)
+	if err != nil {
+		return nil, attempts, err
+	}
+
+	resp.requestEncoding = requestEncoding
+
+	c.dumpResponse(ctx, resp)
+
+	return c.cacheResponse(req, resp, entry), attempts, nil
+}
+
+// readResponse decompresses and reads the body of httpResp, decoding it
+// while it is read when the request asked for streaming.
+func (c Client) readResponse(req *http.Request, httpResp *http.Response) (*APIResponse, error) {
+	responseEncoding, err := decompressResponse(httpResp)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyReader, err := c.limitBody(req, httpResp)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &APIResponse{
+		Response:         httpResp,
+		responseEncoding: responseEncoding,
+	}
+
+	if decode, ok := c.streamable(req, httpResp); ok {
+		if err := decode(bodyReader); err != nil {
+			return nil, err
 		}
 
-		respC <- APIResponse{
-			Response: httpResp,
-			Body:     body,
+		// Let the connection be reused when only trailing whitespace is left.
+		drainBody(httpResp.Body)
+
+		resp.streamed = true
+	} else {
+		resp.Body, err = io.ReadAll(bodyReader)
+		if err != nil {
+			return nil, err
 		}
-	}()
+	}
 
-	select {
-	case <-ctx.Done():
-		return nil, context.DeadlineExceeded
-	case err := <-errC:
-		return nil, err
-	case resp := <-respC:
-		return &resp, nil
+	if responseEncoding != "" {
+		c.logResponseCompression(req.Context(), httpResp, responseEncoding)
 	}
+
+	return resp, nil
 }
 
 var (
@@ -495,7 +712,7 @@ func checkError(resp *APIResponse) error {
 		}
 
 		if err := json.Unmarshal(resp.Body, &e); err != nil {
-			return errors.New(http.StatusText(resp.Response.StatusCode))
+			return StatusError{APIResponse: resp}
 		}
 
 		return e
@@ -504,7 +721,20 @@ func checkError(resp *APIResponse) error {
 
 type APIResponse struct {
 	Response *http.Response
-	Body     []byte
+	// Body is empty when the response was decoded while it was read, as
+	// list operations do.
+	Body []byte
+	// RequestID is the X-Request-ID echoed by the portal, if any.
+	RequestID string
+	// DryRun is set on the placeholder responses of requests that were not
+	// sent because the client is in dry-run mode.
+	DryRun bool
+
+	streamed         bool
+	requestEncoding  string
+	responseEncoding string
+	attempts         int
+	duration         time.Duration
 }
 
 func (a APIResponse) Unmarshal(v interface{}) error {
@@ -515,10 +745,6 @@ type HTTPClient interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
-func shouldRetry(err error) bool {
-	return false
-}
-
 type Status struct {
 	Status  string `json:"status,omitempty"`
 	Message string `json:"message,omitempty"`
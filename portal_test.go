@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -74,11 +75,11 @@ func TestNew(t *testing.T) {
 			err: true,
 		},
 		"with base url": {
-			want: Client{baseURL: "http://example.com", token: "random token"},
+			want: Client{baseURL: "http://example.com", tokenSource: StaticToken("random token")},
 			opt:  []Option{WithBaseURL("http://example.com"), WithToken("random token")},
 		},
 		"with token": {
-			want: Client{token: "random token", baseURL: defaultBaseURL},
+			want: Client{tokenSource: StaticToken("random token"), baseURL: defaultBaseURL},
 			opt:  []Option{WithToken("random token")},
 		},
 	}
@@ -95,7 +96,7 @@ func TestNew(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, client)
 				assert.Equal(t, v.want.baseURL, client.baseURL)
-				assert.Equal(t, v.want.token, client.token)
+				assert.Equal(t, v.want.tokenSource, client.tokenSource)
 			}
 		})
 	}
@@ -111,7 +112,7 @@ func TestOptions(t *testing.T) {
 			opt:  WithBaseURL("http://example.com"),
 		},
 		"with token": {
-			want: Client{token: "random token"},
+			want: Client{tokenSource: StaticToken("random token")},
 			opt:  WithToken("random token"),
 		},
 		"with insecure": {
@@ -144,7 +145,7 @@ func TestOptions(t *testing.T) {
 			client.Apply(v.opt)
 
 			assert.Equal(t, v.want.baseURL, client.baseURL)
-			assert.Equal(t, v.want.token, client.token)
+			assert.Equal(t, v.want.tokenSource, client.tokenSource)
 			assert.Equal(t, v.want.insecure, client.insecure)
 			assert.Equal(t, v.want.readTimeout, client.readTimeout)
 		})
@@ -194,3 +195,126 @@ func TestNewRequest(t *testing.T) {
 func assertRequest(t *testing.T, want, got *http.Request) {
 	assert.Equal(t, want.Method, got.Method, "wanted method %v but got %v", want.Method, got.Method)
 }
+
+func TestPerCallOptions(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		assertHeader(t, r, "Authorization", "OTHER TOKEN")
+		assertHeader(t, r, "X-Client", "client")
+		assertHeader(t, r, "X-Call", "call")
+		assertHeader(t, r, "User-Agent", "Call")
+
+		_, err := w.Write([]byte(`{"ID":1,"Name":"Default Org"}`))
+		assert.NoError(t, err)
+	})
+
+	srv.mux.HandleFunc("/portal-api/organisations", func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, "POST", r)
+		assertHeader(t, r, "Authorization", "OTHER TOKEN")
+
+		_, err := w.Write([]byte(`{"ID":2}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL("http://127.0.0.1:1"),
+		WithToken("TOKEN"),
+		WithHeaders(map[string]string{"X-Client": "client"}),
+	)
+	require.NoError(t, err)
+
+	callOpts := []Option{
+		WithBaseURL(srv.srv.URL),
+		WithToken("OTHER TOKEN"),
+		WithUserAgent("Call"),
+	}
+
+	for _, method := range []string{"GetOrg", "DeleteOrg"} {
+		t.Run(method, func(t *testing.T) {
+			opts := append(callOpts[:len(callOpts):len(callOpts)], WithHeaders(map[string]string{"X-Call": "call"}))
+
+			var err error
+			if method == "GetOrg" {
+				_, err = client.Orgs().GetOrg(context.Background(), 1, opts...)
+			} else {
+				_, err = client.Orgs().DeleteOrg(context.Background(), 1, opts...)
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("skip validation", func(t *testing.T) {
+		_, err := client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{}, callOpts...)
+		assert.Error(t, err)
+
+		_, err = client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{}, append(callOpts, WithSkipValidation())...)
+		assert.NoError(t, err)
+	})
+
+	assert.Equal(t, "client", client.headers.Get("X-Client"))
+	assert.Empty(t, client.headers.Get("X-Call"))
+}
+
+func TestPerformRequest_Cancellation(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/apps/1/provision", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	t.Run("cancelled in flight", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := client.Apps().ProvisionApp(ctx, 1)
+
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Contains(t, err.Error(), "Apps.ProvisionApp")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := client.Apps().ProvisionApp(ctx, 1)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("cancelled before start", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := client.Apps().ProvisionApp(ctx, 1)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Contains(t, err.Error(), "Apps.ProvisionApp")
+	})
+
+	t.Run("no goroutine leak", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+
+		for i := 0; i < 20; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			_, err := client.Apps().ProvisionApp(ctx, 1)
+			cancel()
+			require.Error(t, err)
+		}
+
+		require.NoError(t, client.Close())
+
+		assert.Eventually(t, func() bool {
+			return runtime.NumGoroutine() <= before+2
+		}, 2*time.Second, 20*time.Millisecond)
+	})
+}
@@ -0,0 +1,521 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+// Package recorder provides an HTTP client that records portal API
+// interactions to cassette files and replays them, so tests can run
+// deterministically without a live portal. Plug it into a portal client with
+// portal.WithHTTPClient:
+//
+//	rec, err := recorder.New("testdata/cassettes/orgs.json", recorder.WithMode(recorder.ModeRecord))
+//	...
+//	defer rec.Save()
+//
+//	client, err := portal.New(portal.WithHTTPClient(rec), ...)
+package recorder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// Redacted replaces header values and body fields that hold secrets.
+	Redacted = "[REDACTED]"
+
+	// BodyEncodingBase64 marks bodies that are not valid UTF-8 and are
+	// stored base64-encoded.
+	BodyEncodingBase64 = "base64"
+
+	headerContentEncoding = "Content-Encoding"
+	headerAcceptEncoding  = "Accept-Encoding"
+)
+
+// Mode selects whether a Recorder talks to the portal.
+type Mode int
+
+const (
+	// ModeReplay answers every request from the cassette and never sends
+	// anything to the portal.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request to the portal and records it,
+	// replacing the cassette on Save.
+	ModeRecord
+)
+
+// ErrUnmatched is matched by errors.Is for requests that have no recorded
+// interaction.
+var ErrUnmatched = errors.New("no recorded interaction matches request")
+
+// UnmatchedError is returned in replay mode for a request that matches no
+// interaction in the cassette.
+type UnmatchedError struct {
+	Cassette string
+	Method   string
+	URL      string
+	Body     string
+}
+
+func (e *UnmatchedError) Error() string {
+	msg := fmt.Sprintf("%v: %v %v in cassette %v", ErrUnmatched, e.Method, e.URL, e.Cassette)
+	if e.Body != "" {
+		msg += " with body " + e.Body
+	}
+
+	return msg
+}
+
+func (e *UnmatchedError) Is(target error) bool {
+	return target == ErrUnmatched
+}
+
+// HTTPClient sends requests to the portal while recording. It matches
+// portal.HTTPClient.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// BodyEncoding is BodyEncodingBase64 for binary bodies and empty
+	// otherwise.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// BodyEncoding is BodyEncodingBase64 for binary bodies and empty
+	// otherwise.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// Option configures a Recorder.
+type Option func(r *Recorder)
+
+// WithMode sets the mode. The default is ModeReplay.
+func WithMode(mode Mode) Option {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithHTTPClient sets the client used to reach the portal in record mode.
+// The default is a plain http.Client.
+func WithHTTPClient(client HTTPClient) Option {
+	return func(r *Recorder) {
+		r.client = client
+	}
+}
+
+// WithRedactedHeaders adds headers whose values are replaced by Redacted in
+// the cassette.
+func WithRedactedHeaders(headers ...string) Option {
+	return func(r *Recorder) {
+		for _, h := range headers {
+			r.redactedHeaders = append(r.redactedHeaders, http.CanonicalHeaderKey(h))
+		}
+	}
+}
+
+// WithRedactedKeys adds JSON keys whose values are redacted in recorded
+// bodies, whatever their type: strings are replaced by Redacted, numbers by
+// zero and booleans by false, in nested objects and arrays too. Keys match
+// case-insensitively on substrings, so "secret" also covers "ClientSecret".
+func WithRedactedKeys(keys ...string) Option {
+	return func(r *Recorder) {
+		for _, k := range keys {
+			r.redactedKeys = append(r.redactedKeys, strings.ToLower(k))
+		}
+	}
+}
+
+// Recorder is an HTTP client that records or replays portal interactions.
+// It is safe for concurrent use.
+type Recorder struct {
+	path            string
+	mode            Mode
+	client          HTTPClient
+	redactedHeaders []string
+	redactedKeys    []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New returns a Recorder for the cassette at path. In replay mode the
+// cassette must exist.
+func New(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:   path,
+		client: &http.Client{},
+		redactedHeaders: []string{
+			"Authorization",
+			"Cookie",
+			"Set-Cookie",
+			"Proxy-Authorization",
+			"X-Api-Key",
+		},
+		redactedKeys: []string{"token", "secret", "password", "credential", "apikey"},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("parsing cassette %v: %w", path, err)
+	}
+
+	r.used = make([]bool, len(r.cassette.Interactions))
+
+	return r, nil
+}
+
+// Do records or replays req depending on the mode.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	body, encoding, err := r.requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := Request{
+		Method:       req.Method,
+		Path:         req.URL.Path,
+		Query:        req.URL.Query().Encode(),
+		Header:       r.redactHeader(req.Header),
+		Body:         body,
+		BodyEncoding: encoding,
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, recorded)
+	}
+
+	return r.replay(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded Request) (*http.Response, error) {
+	// Let the transport negotiate compression itself so that it also
+	// decompresses the response and the cassette holds plain bodies.
+	out := req.Clone(req.Context())
+	out.Header.Del(headerAcceptEncoding)
+
+	resp, err := r.client.Do(out)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	body, encoding := r.redactBody(data)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode:   resp.StatusCode,
+			Header:       r.redactHeader(resp.Header),
+			Body:         body,
+			BodyEncoding: encoding,
+		},
+	})
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Interactions are replayed in order. Once every matching interaction
+	// has been used, the last one keeps answering, so polling works.
+	last := -1
+
+	for i, interaction := range r.cassette.Interactions {
+		if !matches(interaction.Request, recorded) {
+			continue
+		}
+
+		last = i
+
+		if !r.used[i] {
+			break
+		}
+	}
+
+	if last < 0 {
+		return nil, &UnmatchedError{
+			Cassette: r.path,
+			Method:   req.Method,
+			URL:      req.URL.String(),
+			Body:     recorded.Body,
+		}
+	}
+
+	r.used[last] = true
+
+	return newResponse(req, r.cassette.Interactions[last].Response)
+}
+
+// Unused returns the recorded interactions that were never replayed, which
+// usually means the code under test no longer makes those calls.
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+
+	for i, used := range r.used {
+		if !used {
+			unused = append(unused, r.cassette.Interactions[i])
+		}
+	}
+
+	return unused
+}
+
+// Save writes the recorded interactions to the cassette file. It does
+// nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("creating cassette directory: %w", err)
+	}
+
+	return os.WriteFile(r.path, append(data, '\n'), 0o600)
+}
+
+func matches(recorded, req Request) bool {
+	return recorded.Method == req.Method &&
+		recorded.Path == req.Path &&
+		recorded.Query == req.Query &&
+		recorded.Body == req.Body &&
+		recorded.BodyEncoding == req.BodyEncoding
+}
+
+func newResponse(req *http.Request, recorded Response) (*http.Response, error) {
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	body := []byte(recorded.Body)
+
+	if recorded.BodyEncoding == BodyEncodingBase64 {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(recorded.Body); err != nil {
+			return nil, fmt.Errorf("decoding recorded body: %w", err)
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// requestBody returns the redacted, decompressed body of req and its
+// encoding, leaving req readable.
+func (r *Recorder) requestBody(req *http.Request) (string, string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", "", nil
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+
+	if data, err = decompress(req.Header.Get(headerContentEncoding), data); err != nil {
+		return "", "", err
+	}
+
+	body, encoding := r.redactBody(data)
+
+	return body, encoding, nil
+}
+
+// decompress decodes a body sent with the given Content-Encoding.
+func decompress(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		return io.ReadAll(zr)
+	case "zstd":
+		zr, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		return zr.DecodeAll(data, nil)
+	default:
+		return data, nil
+	}
+}
+
+func (r *Recorder) redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+
+	for _, k := range r.redactedHeaders {
+		if _, ok := out[k]; ok {
+			out[k] = []string{Redacted}
+		}
+	}
+
+	return out
+}
+
+// redactBody redacts secrets in JSON bodies and normalises them, so that
+// bodies match regardless of key order and whitespace. Other text bodies are
+// kept as they are and binary ones are base64-encoded, with the returned
+// encoding set to BodyEncodingBase64.
+func (r *Recorder) redactBody(data []byte) (string, string) {
+	if len(data) == 0 {
+		return "", ""
+	}
+
+	var v interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err == nil {
+		if out, err := json.Marshal(r.redactValue(v)); err == nil {
+			return string(out), ""
+		}
+	}
+
+	if !utf8.Valid(data) {
+		return base64.StdEncoding.EncodeToString(data), BodyEncodingBase64
+	}
+
+	return string(data), ""
+}
+
+func (r *Recorder) redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, value := range t {
+			if r.sensitive(k) {
+				t[k] = redactAll(value)
+				continue
+			}
+
+			t[k] = r.redactValue(value)
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = r.redactValue(t[i])
+		}
+	}
+
+	return v
+}
+
+// redactAll redacts every value in v while keeping its shape, so that
+// replayed bodies still decode: strings become Redacted, numbers zero and
+// booleans false.
+func redactAll(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return Redacted
+	case json.Number:
+		return json.Number("0")
+	case bool:
+		return false
+	case map[string]interface{}:
+		for k, value := range t {
+			t[k] = redactAll(value)
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = redactAll(t[i])
+		}
+	}
+
+	return v
+}
+
+func (r *Recorder) sensitive(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, k := range r.redactedKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
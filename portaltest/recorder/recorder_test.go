@@ -0,0 +1,227 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	portal "github.com/TykTechnologies/portal-go"
+)
+
+func newPortal(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/portal-api/organisations", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"Name":"Acme"}`, string(body))
+
+		_, err = w.Write([]byte(`{"ID":7,"Name":"Acme"}`))
+		assert.NoError(t, err)
+	})
+
+	mux.HandleFunc("/portal-api/apps/1", func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"ID":1,"Name":"App","Credentials":[{"ClientSecret":"s3cr3t"}]}`))
+		assert.NoError(t, err)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	srv := newPortal(t)
+	cassette := filepath.Join(t.TempDir(), "cassettes", "orgs.json")
+
+	rec, err := New(cassette, WithMode(ModeRecord))
+	require.NoError(t, err)
+
+	client, err := portal.New(
+		portal.WithBaseURL(srv.URL),
+		portal.WithToken("SUPER-SECRET-TOKEN"),
+		portal.WithHTTPClient(rec),
+	)
+	require.NoError(t, err)
+
+	created, err := client.Orgs().CreateOrg(context.Background(), &portal.CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), created.Data.ID)
+
+	_, err = client.Apps().GetApp(context.Background(), 1)
+	require.NoError(t, err)
+
+	require.NoError(t, rec.Save())
+	srv.Close()
+
+	data, err := os.ReadFile(cassette)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "SUPER-SECRET-TOKEN")
+	assert.NotContains(t, string(data), "s3cr3t")
+	assert.Contains(t, string(data), Redacted)
+
+	replayer, err := New(cassette)
+	require.NoError(t, err)
+
+	client, err = portal.New(
+		portal.WithBaseURL(srv.URL),
+		portal.WithToken("ANOTHER-TOKEN"),
+		portal.WithHTTPClient(replayer),
+	)
+	require.NoError(t, err)
+
+	created, err = client.Orgs().CreateOrg(context.Background(), &portal.CreateOrgInput{Name: "Acme"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), created.Data.ID)
+	assert.Equal(t, "Acme", created.Data.Name)
+
+	app, err := client.Apps().GetApp(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "App", app.Data.Name)
+
+	assert.Empty(t, replayer.Unused())
+}
+
+func TestRecorder_Unmatched(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "orgs.json")
+
+	data, err := json.Marshal(Cassette{Interactions: []Interaction{{
+		Request:  Request{Method: http.MethodPost, Path: "/portal-api/organisations", Body: `{"Name":"Acme"}`},
+		Response: Response{StatusCode: http.StatusOK, Body: `{"ID":7,"Name":"Acme"}`},
+	}}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cassette, data, 0o600))
+
+	rec, err := New(cassette)
+	require.NoError(t, err)
+
+	client, err := portal.New(
+		portal.WithBaseURL("http://portal.invalid"),
+		portal.WithToken("TOKEN"),
+		portal.WithHTTPClient(rec),
+		portal.WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().CreateOrg(context.Background(), &portal.CreateOrgInput{Name: "Other"})
+	require.ErrorIs(t, err, ErrUnmatched)
+
+	var unmatched *UnmatchedError
+	require.True(t, errors.As(err, &unmatched))
+	assert.Equal(t, http.MethodPost, unmatched.Method)
+	assert.Equal(t, `{"Name":"Other"}`, unmatched.Body)
+	assert.Contains(t, err.Error(), cassette)
+
+	assert.Len(t, rec.Unused(), 1)
+
+	_, err = New(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "reading cassette")
+}
+
+func TestRecorder_ReplayOrder(t *testing.T) {
+	rec := &Recorder{
+		cassette: Cassette{Interactions: []Interaction{
+			{Request: Request{Method: http.MethodGet, Path: "/a"}, Response: Response{StatusCode: 200, Body: "first"}},
+			{Request: Request{Method: http.MethodGet, Path: "/a"}, Response: Response{StatusCode: 200, Body: "second"}},
+		}},
+		used: make([]bool, 2),
+	}
+
+	for _, want := range []string{"first", "second", "second"} {
+		req := httptest.NewRequest(http.MethodGet, "http://portal/a", http.NoBody)
+
+		resp, err := rec.Do(req)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(body))
+	}
+}
+
+func TestRecorder_CompressedAndBinaryBodies(t *testing.T) {
+	binary := []byte{0xff, 0x00, 0xfe, 'P', 'K'}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write(binary)
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	zw, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	compressed := zw.EncodeAll([]byte(`{"Name": "Acme"}`), nil)
+	require.NoError(t, zw.Close())
+
+	send := func(rec *Recorder, body []byte, encoding string) []byte {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/upload", bytes.NewReader(body))
+		require.NoError(t, err)
+
+		if encoding != "" {
+			req.Header.Set(headerContentEncoding, encoding)
+		}
+
+		resp, err := rec.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return data
+	}
+
+	cassette := filepath.Join(t.TempDir(), "upload.json")
+
+	rec, err := New(cassette, WithMode(ModeRecord))
+	require.NoError(t, err)
+
+	assert.Equal(t, binary, send(rec, compressed, "zstd"))
+	assert.Equal(t, binary, send(rec, binary, ""))
+	require.NoError(t, rec.Save())
+
+	data, err := os.ReadFile(cassette)
+	require.NoError(t, err)
+
+	var saved Cassette
+	require.NoError(t, json.Unmarshal(data, &saved))
+	require.Len(t, saved.Interactions, 2)
+	assert.Equal(t, `{"Name":"Acme"}`, saved.Interactions[0].Request.Body)
+	assert.Equal(t, BodyEncodingBase64, saved.Interactions[1].Request.BodyEncoding)
+	assert.Equal(t, BodyEncodingBase64, saved.Interactions[1].Response.BodyEncoding)
+
+	replayer, err := New(cassette)
+	require.NoError(t, err)
+
+	assert.Equal(t, binary, send(replayer, compressed, "zstd"))
+	assert.Equal(t, binary, send(replayer, binary, ""))
+	assert.Empty(t, replayer.Unused())
+}
+
+func TestRecorder_RedactsValuesOfAnyType(t *testing.T) {
+	rec, err := New(filepath.Join(t.TempDir(), "apps.json"), WithMode(ModeRecord))
+	require.NoError(t, err)
+
+	body, encoding := rec.redactBody([]byte(`{"Name":"app","ClientSecret":{"Value":"s3cr3t"},"ApiKey":12345,"Token":["abc"],"PasswordSet":true}`))
+	assert.Empty(t, encoding)
+	assert.JSONEq(t, `{
+		"Name": "app",
+		"ClientSecret": {"Value": "[REDACTED]"},
+		"ApiKey": 0,
+		"Token": ["[REDACTED]"],
+		"PasswordSet": false
+	}`, body)
+}
@@ -28,12 +28,14 @@ type products struct {
 }
 
 func (p products) CreateProduct(ctx context.Context, input *CreateProductInput, opts ...Option) (*CreateProductOutput, error) {
+	ctx = withOperation(ctx, "Products.CreateProduct")
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPost(ctx, pathProducts, bytes.NewReader(payload), nil)
+	resp, err := p.client.doPost(ctx, pathProducts, bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +47,16 @@ func (p products) CreateProduct(ctx context.Context, input *CreateProductInput,
 	}
 
 	return &CreateProductOutput{
-		Data: &product,
+		Data:   &product,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p products) GetProduct(ctx context.Context, id int64, opts ...Option) (*GetProductOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathProduct, id), nil)
+	ctx = withOperation(ctx, "Products.GetProduct", id)
+
+	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathProduct, id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -62,33 +68,33 @@ func (p products) GetProduct(ctx context.Context, id int64, opts ...Option) (*Ge
 
 	return &GetProductOutput{
 		Data: &product,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p products) ListProducts(ctx context.Context, options *ListProductsInput, opts ...Option) (*ListProductsOutput, error) {
-	resp, err := p.client.doGet(ctx, pathProducts, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var products []Product
+	ctx = withOperation(ctx, "Products.ListProducts")
 
-	if err := resp.Unmarshal(&products); err != nil {
+	products, resp, err := listItems[Product](ctx, p.client, pathProducts, nil, opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListProductsOutput{
 		Data: products,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p products) UpdateProduct(ctx context.Context, id int64, input *UpdateProductInput, opts ...Option) (*UpdateProductOutput, error) {
+	ctx = withOperation(ctx, "Products.UpdateProduct", id)
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathProduct, id), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathProduct, id), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +106,9 @@ func (p products) UpdateProduct(ctx context.Context, id int64, input *UpdateProd
 	}
 
 	return &UpdateProductOutput{
-		Data: &product,
+		Data:   &product,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
@@ -120,6 +128,7 @@ type ListProductsInput struct{}
 
 type ListProductsOutput struct {
 	Data []Product
+	Meta Meta
 }
 
 type Product struct {
@@ -153,7 +162,9 @@ type APIDetails struct {
 }
 
 type ProductOutput struct {
-	Data *Product
+	Data   *Product
+	DryRun bool
+	Meta   Meta
 }
 
 type UpdateProductOutput = ProductOutput
@@ -0,0 +1,364 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by NewFromEnv and NewFromProfile. All but
+// PORTAL_CONFIG and PORTAL_CONTEXT override the selected profile.
+const (
+	envVarConfig         = "PORTAL_CONFIG"
+	envVarContext        = "PORTAL_CONTEXT"
+	envVarURL            = "PORTAL_URL"
+	envVarToken          = "PORTAL_TOKEN"
+	envVarTokenFile      = "PORTAL_TOKEN_FILE"
+	envVarCAFile         = "PORTAL_CA_FILE"
+	envVarInsecure       = "PORTAL_INSECURE"
+	envVarConnectTimeout = "PORTAL_CONNECT_TIMEOUT"
+	envVarReadTimeout    = "PORTAL_READ_TIMEOUT"
+)
+
+// Config is a configuration file holding named portal profiles, in the
+// spirit of a kubeconfig. It is written in YAML or JSON:
+//
+//	current-context: prod
+//	contexts:
+//	  - name: prod
+//	    base-url: https://portal.eu.example.com
+//	    secondary-urls: [https://portal.us.example.com]
+//	    token-command: [vault, kv, get, -field=token, secret/portal]
+//	    ca-file: ca.pem
+//	    connect-timeout: 5s
+//	    read-timeout: 30 # seconds
+//	    headers:
+//	      X-Team: platform
+//	  - name: dev
+//	    base-url: http://localhost:3001
+//	    token-file: dev-token
+//
+// Relative file paths are resolved against the directory of the file.
+// Timeouts are durations such as "1m30s" or whole numbers of seconds.
+type Config struct {
+	CurrentContext string    `yaml:"current-context" json:"current-context"`
+	Contexts       []Profile `yaml:"contexts" json:"contexts"`
+}
+
+// Profile holds the settings of one portal. Exactly one of Token, TokenFile
+// and TokenCommand should be set.
+type Profile struct {
+	Name          string   `yaml:"name" json:"name"`
+	BaseURL       string   `yaml:"base-url" json:"base-url"`
+	SecondaryURLs []string `yaml:"secondary-urls" json:"secondary-urls"`
+	Token         string   `yaml:"token" json:"token"`
+	TokenFile     string   `yaml:"token-file" json:"token-file"`
+	// TokenCommand is a command and its arguments printing the token.
+	TokenCommand   []string          `yaml:"token-command" json:"token-command"`
+	CAFile         string            `yaml:"ca-file" json:"ca-file"`
+	Insecure       bool              `yaml:"insecure" json:"insecure"`
+	ConnectTimeout Duration          `yaml:"connect-timeout" json:"connect-timeout"`
+	ReadTimeout    Duration          `yaml:"read-timeout" json:"read-timeout"`
+	Headers        map[string]string `yaml:"headers" json:"headers"`
+}
+
+// DefaultConfigPath returns the path of the configuration file: the value
+// of PORTAL_CONFIG, or ~/.tyk/portal.yaml.
+func DefaultConfigPath() (string, error) {
+	if path := os.Getenv(envVarConfig); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".tyk", "portal.yaml"), nil
+}
+
+// LoadConfig reads a configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading portal config: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing portal config %v: %w", path, err)
+	}
+
+	names := map[string]bool{}
+	dir := filepath.Dir(path)
+
+	for i := range cfg.Contexts {
+		p := &cfg.Contexts[i]
+
+		if p.Name == "" {
+			return nil, fmt.Errorf("portal config %v: context %d has no name", path, i)
+		}
+
+		if names[p.Name] {
+			return nil, fmt.Errorf("portal config %v: duplicate context %q", path, p.Name)
+		}
+
+		names[p.Name] = true
+		p.TokenFile = resolvePath(dir, p.TokenFile)
+		p.CAFile = resolvePath(dir, p.CAFile)
+	}
+
+	return &cfg, nil
+}
+
+// Profile returns the context called name, or the current context when name
+// is empty.
+func (c Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.CurrentContext
+	}
+
+	if name == "" {
+		return Profile{}, errors.New("no portal context selected and no current-context set")
+	}
+
+	for _, p := range c.Contexts {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	return Profile{}, fmt.Errorf("portal context %q not found", name)
+}
+
+// Options maps the profile onto client options. Unset fields keep the
+// client's defaults.
+func (p Profile) Options() []Option {
+	var opts []Option
+
+	switch {
+	case p.BaseURL != "" && len(p.SecondaryURLs) > 0:
+		opts = append(opts, WithBaseURLs(p.BaseURL, p.SecondaryURLs...))
+	case p.BaseURL != "":
+		opts = append(opts, WithBaseURL(p.BaseURL))
+	}
+
+	switch {
+	case p.Token != "":
+		opts = append(opts, WithToken(p.Token))
+	case p.TokenFile != "":
+		opts = append(opts, WithTokenSource(FileToken(p.TokenFile)))
+	case len(p.TokenCommand) > 0:
+		opts = append(opts, WithTokenSource(CommandToken(p.TokenCommand[0], p.TokenCommand[1:]...)))
+	}
+
+	if p.CAFile != "" {
+		opts = append(opts, WithRootCAFile(p.CAFile))
+	}
+
+	if p.Insecure {
+		opts = append(opts, WithInsecure(true))
+	}
+
+	if p.ConnectTimeout > 0 {
+		opts = append(opts, WithConnectTimeout(time.Duration(p.ConnectTimeout)))
+	}
+
+	if p.ReadTimeout > 0 {
+		opts = append(opts, WithReadTimeout(time.Duration(p.ReadTimeout)))
+	}
+
+	if len(p.Headers) > 0 {
+		opts = append(opts, WithHeaders(p.Headers))
+	}
+
+	return opts
+}
+
+// NewFromEnv creates a client from the configuration file, when there is
+// one, and the PORTAL_* environment variables. The profile is the one named
+// by PORTAL_CONTEXT or else the file's current context. opts are applied
+// last.
+func NewFromEnv(opts ...Option) (*Client, error) {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var profile Profile
+
+	if _, err := os.Stat(path); err == nil || os.Getenv(envVarConfig) != "" {
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if name := os.Getenv(envVarContext); name != "" || cfg.CurrentContext != "" {
+			if profile, err = cfg.Profile(name); err != nil {
+				return nil, err
+			}
+		}
+	} else if name := os.Getenv(envVarContext); name != "" {
+		return nil, fmt.Errorf("portal context %q not found: no config file at %v", name, path)
+	}
+
+	return newFromProfile(profile, opts...)
+}
+
+// NewFromProfile creates a client from the context called name in the
+// configuration file, or from its current context when name is empty.
+// PORTAL_* environment variables override the profile and opts are applied
+// last.
+func NewFromProfile(name string, opts ...Option) (*Client, error) {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := cfg.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFromProfile(profile, opts...)
+}
+
+func newFromProfile(profile Profile, opts ...Option) (*Client, error) {
+	envOpts, err := envOptions(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(profile.Options(), envOpts...)
+
+	return New(append(all, opts...)...)
+}
+
+// envOptions maps the PORTAL_* environment variables onto options.
+// PORTAL_URL replaces the profile's base URL and keeps its secondary URLs.
+func envOptions(profile Profile) ([]Option, error) {
+	var opts []Option
+
+	switch v := os.Getenv(envVarURL); {
+	case v != "" && len(profile.SecondaryURLs) > 0:
+		opts = append(opts, WithBaseURLs(v, profile.SecondaryURLs...))
+	case v != "":
+		opts = append(opts, WithBaseURL(v))
+	}
+
+	if v := os.Getenv(envVarTokenFile); v != "" {
+		opts = append(opts, WithTokenSource(FileToken(v)))
+	}
+
+	if v := os.Getenv(envVarToken); v != "" {
+		opts = append(opts, WithToken(v))
+	}
+
+	if v := os.Getenv(envVarCAFile); v != "" {
+		opts = append(opts, WithRootCAFile(v))
+	}
+
+	if v := os.Getenv(envVarInsecure); v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", envVarInsecure, err)
+		}
+
+		opts = append(opts, WithInsecure(insecure))
+	}
+
+	for _, d := range []struct {
+		name   string
+		option func(time.Duration) Option
+	}{
+		{envVarConnectTimeout, WithConnectTimeout},
+		{envVarReadTimeout, WithReadTimeout},
+	} {
+		v := os.Getenv(d.name)
+		if v == "" {
+			continue
+		}
+
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", d.name, err)
+		}
+
+		opts = append(opts, d.option(timeout))
+	}
+
+	return opts, nil
+}
+
+// Duration is a time.Duration read from a configuration file, either as a
+// string such as "1m30s" or as a whole number of seconds.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.parse(node.Value, node.Tag == "!!str")
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return d.parse(string(b), false)
+	}
+
+	return d.parse(s, true)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(time.Duration(d).String())), nil
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) parse(s string, quoted bool) error {
+	if quoted {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+
+		*d = Duration(v)
+
+		return nil
+	}
+
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || seconds < 0 || seconds > math.MaxInt64/int64(time.Second) {
+		return fmt.Errorf("invalid duration %v: use a string such as \"30s\" or a whole number of seconds", s)
+	}
+
+	*d = Duration(time.Duration(seconds) * time.Second)
+
+	return nil
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
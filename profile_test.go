@@ -0,0 +1,150 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	t.Setenv(envVarConfig, path)
+
+	return path
+}
+
+func TestNewFromProfile(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "FILE-TOKEN", r.Header.Get(headerAuthorization))
+		assert.Equal(t, "platform", r.Header.Get("X-Team"))
+
+		_, err := w.Write([]byte(`{"ID":1}`))
+		assert.NoError(t, err)
+	})
+
+	path := writeConfig(t, "portal.yaml", `
+current-context: prod
+contexts:
+  - name: prod
+    base-url: https://portal.example.com
+    token: PROD-TOKEN
+  - name: dev
+    base-url: `+srv.srv.URL+`
+    token-file: dev-token
+    connect-timeout: 5s
+    read-timeout: 30s
+    headers:
+      X-Team: platform
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(path), "dev-token"), []byte("FILE-TOKEN\n"), 0o600))
+
+	client, err := NewFromProfile("dev")
+	require.NoError(t, err)
+
+	assert.Equal(t, srv.srv.URL, client.baseURL)
+	assert.Equal(t, 5*time.Second, client.connectTimeout)
+	assert.Equal(t, 30*time.Second, client.readTimeout)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+
+	client, err = NewFromProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, "https://portal.example.com", client.baseURL)
+	assert.Equal(t, StaticToken("PROD-TOKEN"), client.tokenSource)
+
+	_, err = NewFromProfile("staging")
+	require.EqualError(t, err, `portal context "staging" not found`)
+}
+
+func TestNewFromEnv(t *testing.T) {
+	writeConfig(t, "portal.json", `{
+  "current-context": "prod",
+  "contexts": [
+    {"name": "prod", "base-url": "https://prod.example.com", "token": "PROD-TOKEN"},
+    {"name": "staging", "base-url": "https://staging.example.com", "secondary-urls": ["https://staging-2.example.com"], "token": "STAGING-TOKEN", "read-timeout": 30}
+  ]
+}`)
+
+	client, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod.example.com", client.baseURL)
+
+	t.Setenv(envVarContext, "staging")
+
+	client, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "https://staging.example.com", client.ActiveEndpoint())
+	assert.Equal(t, []string{"https://staging.example.com", "https://staging-2.example.com"}, client.failover.endpoints)
+	assert.Equal(t, 30*time.Second, client.readTimeout)
+
+	t.Setenv(envVarURL, "https://override.example.com")
+	t.Setenv(envVarToken, "ENV-TOKEN")
+	t.Setenv(envVarReadTimeout, "2s")
+
+	client, err = NewFromEnv(WithReadTimeout(3 * time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "https://override.example.com", client.baseURL)
+	assert.Equal(t, []string{"https://override.example.com", "https://staging-2.example.com"}, client.failover.endpoints)
+	assert.Equal(t, StaticToken("ENV-TOKEN"), client.tokenSource)
+	assert.Equal(t, 3*time.Second, client.readTimeout)
+
+	t.Setenv(envVarInsecure, "maybe")
+
+	_, err = NewFromEnv()
+	require.Error(t, err)
+}
+
+func TestNewFromEnv_WithoutConfigFile(t *testing.T) {
+	t.Setenv(envVarConfig, "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(envVarURL, "https://portal.example.com")
+	t.Setenv(envVarToken, "TOKEN")
+
+	client, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "https://portal.example.com", client.baseURL)
+
+	t.Setenv(envVarContext, "prod")
+
+	_, err = NewFromEnv()
+	require.Error(t, err)
+}
+
+func TestDuration(t *testing.T) {
+	var p Profile
+
+	require.NoError(t, yaml.Unmarshal([]byte("connect-timeout: 5s\nread-timeout: 30\n"), &p))
+	assert.Equal(t, Duration(5*time.Second), p.ConnectTimeout)
+	assert.Equal(t, Duration(30*time.Second), p.ReadTimeout)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"connect-timeout": "2s", "read-timeout": 1}`), &p))
+	assert.Equal(t, Duration(2*time.Second), p.ConnectTimeout)
+	assert.Equal(t, Duration(time.Second), p.ReadTimeout)
+
+	require.Error(t, yaml.Unmarshal([]byte("read-timeout: soon\n"), &p))
+	require.Error(t, json.Unmarshal([]byte(`{"read-timeout": 1.5}`), &p))
+	require.Error(t, yaml.Unmarshal([]byte("read-timeout: -5\n"), &p))
+
+	out, err := json.Marshal(Profile{ReadTimeout: Duration(30 * time.Second)})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"read-timeout":"30s"`)
+}
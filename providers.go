@@ -32,12 +32,14 @@ type providers struct {
 }
 
 func (p providers) CreateProvider(ctx context.Context, input *CreateProviderInput, opts ...Option) (*CreateProviderOutput, error) {
+	ctx = withOperation(ctx, "Providers.CreateProvider")
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPost(ctx, pathProviders, bytes.NewReader(payload), nil)
+	resp, err := p.client.doPost(ctx, pathProviders, bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -50,11 +52,15 @@ func (p providers) CreateProvider(ctx context.Context, input *CreateProviderInpu
 
 	return &CreateProviderOutput{
 		Provider: &provider,
+		DryRun:   resp.DryRun,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 func (p providers) GetProvider(ctx context.Context, id int64, opts ...Option) (*GetProviderOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathProvider, id), nil)
+	ctx = withOperation(ctx, "Providers.GetProvider", id)
+
+	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathProvider, id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -66,32 +72,35 @@ func (p providers) GetProvider(ctx context.Context, id int64, opts ...Option) (*
 
 	return &GetProviderOutput{
 		Provider: &provider,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 func (p providers) DeleteProvider(ctx context.Context, id int64, opts ...Option) (*DeleteProviderOutput, error) {
-	_, err := p.client.doDelete(ctx, fmt.Sprintf(pathProvider, id), nil, nil)
+	ctx = withOperation(ctx, "Providers.DeleteProvider", id)
+
+	resp, err := p.client.doDelete(ctx, fmt.Sprintf(pathProvider, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &GetProviderOutput{}, nil
+	return &GetProviderOutput{
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
+	}, nil
 }
 
 func (p providers) ListProviders(ctx context.Context, options *ListProvidersInput, opts ...Option) (*ListProvidersOutput, error) {
-	resp, err := p.client.doGet(ctx, pathProviders, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var providers []Provider
+	ctx = withOperation(ctx, "Providers.ListProviders")
 
-	if err := resp.Unmarshal(&providers); err != nil {
+	providers, resp, err := listItems[Provider](ctx, p.client, pathProviders, nil, opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListProvidersOutput{
 		Data: providers,
+		Meta: resp.Meta(),
 	}, nil
 }
 
@@ -101,16 +110,18 @@ func (p providers) UpdateProvider(
 	input *UpdateProviderInput,
 	opts ...Option,
 ) (*UpdateProviderOutput, error) {
+	ctx = withOperation(ctx, "Providers.UpdateProvider", id)
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := input.validate(); err != nil {
+	if err := p.client.validateInput(input, opts...); err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathProvider, id), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathProvider, id), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -123,11 +134,15 @@ func (p providers) UpdateProvider(
 
 	return &UpdateProviderOutput{
 		Provider: &provider,
+		DryRun:   resp.DryRun,
+		Meta:     resp.Meta(),
 	}, nil
 }
 
 func (p providers) SyncProvider(ctx context.Context, id int64, opts ...Option) (*SyncProviderOutput, error) {
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathProviderSync, id), nil, nil)
+	ctx = withOperation(ctx, "Providers.SyncProvider", id)
+
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathProviderSync, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -139,12 +154,16 @@ func (p providers) SyncProvider(ctx context.Context, id int64, opts ...Option) (
 	}
 
 	return &SyncProviderOutput{
-		Data: msg,
+		Data:   msg,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p providers) SyncProviders(ctx context.Context, opts ...Option) (*SyncProviderOutput, error) {
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathProviderSync, "all"), nil, nil)
+	ctx = withOperation(ctx, "Providers.SyncProviders")
+
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathProviderSync, "all"), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -156,7 +175,9 @@ func (p providers) SyncProviders(ctx context.Context, opts ...Option) (*SyncProv
 	}
 
 	return &SyncProviderOutput{
-		Data: msg,
+		Data:   msg,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
@@ -183,6 +204,7 @@ type ListProvidersInput struct{}
 
 type ListProvidersOutput struct {
 	Data []Provider
+	Meta Meta
 }
 
 type Provider struct {
@@ -203,10 +225,14 @@ type ProviderConfiguration struct {
 
 type ProviderOutput struct {
 	Provider *Provider
+	DryRun   bool
+	Meta     Meta
 }
 
 type SyncProviderOutput struct {
-	Data SyncStatus
+	Data   SyncStatus
+	DryRun bool
+	Meta   Meta
 }
 
 type UpdateProviderOutput = ProviderOutput
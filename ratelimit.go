@@ -0,0 +1,230 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+
+	// rateLimitMinFactor bounds how far the limiter slows down relative to
+	// the configured rate.
+	rateLimitMinFactor = 1.0 / 16
+	// rateLimitRecoverStep is the fraction of the configured rate regained
+	// after each successful response.
+	rateLimitRecoverStep = 0.05
+	// unixTimestampThreshold separates reset values given as a Unix time
+	// from values given in seconds.
+	unixTimestampThreshold = 1e9
+)
+
+// WithRateLimit limits the client to rps requests per second with bursts of
+// up to burst requests. The limiter is shared by every sub-client of the
+// Client. It halves the rate when the portal answers 429, pauses when the
+// rate-limit headers report an exhausted quota and then recovers gradually.
+// Pauses asked for by Retry-After are capped like the retry policy's waits.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.rateLimiter = newRateLimiter(rps, burst)
+	}
+}
+
+// WithMaxConcurrency caps the number of requests in flight at once across all
+// sub-clients of the Client. A request stays in flight until its response
+// body has been read.
+func WithMaxConcurrency(n int) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.inflight = nil
+			return
+		}
+
+		c.inflight = make(chan struct{}, n)
+	}
+}
+
+type rateLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	maxRate     float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:    rps,
+		maxRate: rps,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+
+		now := time.Now()
+		l.refill(now)
+
+		var d time.Duration
+
+		switch {
+		case now.Before(l.pausedUntil):
+			d = l.pausedUntil.Sub(now)
+		case l.tokens >= 1:
+			l.tokens--
+			l.mu.Unlock()
+
+			return nil
+		default:
+			d = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+
+		l.mu.Unlock()
+
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *rateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+
+	if elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed*l.rate)
+	}
+}
+
+// observe adapts the rate to the response of an attempt. A Retry-After
+// pause is capped at maxPause.
+func (l *rateLimiter) observe(resp *http.Response, maxPause time.Duration) {
+	if resp == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.refill(now)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		l.rate = math.Max(l.rate/2, l.maxRate*rateLimitMinFactor)
+		l.tokens = math.Min(l.tokens, 0)
+
+		if d, ok := retryAfter(resp); ok {
+			l.pause(now.Add(min(d, maxPause)))
+		}
+
+		return
+	}
+
+	if remaining, err := strconv.Atoi(resp.Header.Get(headerRateLimitRemaining)); err == nil && remaining <= 0 {
+		if reset, ok := rateLimitReset(resp, now); ok {
+			l.pause(reset)
+		}
+	}
+
+	if resp.StatusCode < http.StatusBadRequest && l.rate < l.maxRate {
+		l.rate = math.Min(l.maxRate, l.rate+l.maxRate*rateLimitRecoverStep)
+	}
+}
+
+func (l *rateLimiter) pause(until time.Time) {
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+func (l *rateLimiter) currentRate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate
+}
+
+// rateLimitReset parses X-RateLimit-Reset, given either as a Unix time or as
+// a number of seconds from now.
+func rateLimitReset(resp *http.Response, now time.Time) (time.Time, bool) {
+	value, err := strconv.ParseInt(resp.Header.Get(headerRateLimitReset), 10, 64)
+	if err != nil || value < 0 {
+		return time.Time{}, false
+	}
+
+	if value > unixTimestampThreshold {
+		return time.Unix(value, 0), true
+	}
+
+	return now.Add(time.Duration(value) * time.Second), true
+}
+
+// acquire waits for a rate-limit token and a concurrency slot. The returned
+// function releases the slot; see holdUntilClosed.
+func (c Client) acquire(ctx context.Context) (func(), error) {
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.inflight == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case c.inflight <- struct{}{}:
+		return func() { <-c.inflight }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// holdUntilClosed keeps the concurrency slot of an attempt until its
+// response body is closed, so that bodies still being read, such as
+// streamed lists, count as in flight. Failed attempts release it at once.
+func holdUntilClosed(resp *http.Response, err error, release func()) {
+	if err != nil || resp == nil {
+		release()
+		return
+	}
+
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+}
+
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+
+	return err
+}
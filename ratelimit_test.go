@@ -0,0 +1,190 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit_Throttles(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/users", func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"ID":1}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithRateLimit(50, 1),
+	)
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	for i := 0; i < 6; i++ {
+		_, err := client.Users().CreateUser(context.Background(), &CreateUserInput{Email: "a@b.c", First: "A"})
+		require.NoError(t, err)
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestRateLimit_MaxConcurrency(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var current, peak int32
+
+	srv.mux.HandleFunc("/portal-api/users/1", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		_, err := w.Write([]byte(`{"ID":1}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxConcurrency(2),
+	)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := client.Users().GetUser(context.Background(), 1)
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+}
+
+func TestRateLimiter_Adapts(t *testing.T) {
+	l := newRateLimiter(100, 10)
+
+	l.observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}, time.Second)
+	assert.Equal(t, 50.0, l.currentRate())
+
+	for i := 0; i < 10; i++ {
+		l.observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}, time.Second)
+	}
+
+	assert.Equal(t, 100*rateLimitMinFactor, l.currentRate())
+
+	for i := 0; i < 100; i++ {
+		l.observe(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, time.Second)
+	}
+
+	assert.Equal(t, 100.0, l.currentRate())
+}
+
+func TestRateLimiter_PausesOnExhaustedQuota(t *testing.T) {
+	l := newRateLimiter(1000, 10)
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set(headerRateLimitRemaining, "0")
+	resp.Header.Set(headerRateLimitReset, strconv.Itoa(60))
+
+	l.observe(resp, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, l.wait(ctx), context.DeadlineExceeded)
+}
+
+func TestRateLimiter_CapsRetryAfter(t *testing.T) {
+	l := newRateLimiter(1000, 10)
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set(headerRetryAfter, "86400")
+
+	l.observe(resp, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, l.wait(ctx))
+}
+
+func TestRateLimit_MaxConcurrencyCoversStreamedBodies(t *testing.T) {
+	srv := newUsersServer(t, 3)
+	defer srv.Close()
+
+	var requests int32
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		_, err := w.Write([]byte(`{"ID":1}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxConcurrency(1),
+	)
+	require.NoError(t, err)
+
+	reading := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		<-reading
+
+		_, err := client.Orgs().GetOrg(context.Background(), 1)
+		assert.NoError(t, err)
+	}()
+
+	first := true
+
+	_, err = client.Users().ListUsers(context.Background(), nil, WithItemCallback(func(u User) error {
+		if first {
+			first = false
+
+			close(reading)
+			time.Sleep(50 * time.Millisecond)
+			assert.Zero(t, atomic.LoadInt32(&requests), "the list is still being read")
+		}
+
+		return nil
+	}))
+	require.NoError(t, err)
+
+	<-done
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
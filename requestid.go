@@ -0,0 +1,61 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const headerRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying id. Requests made with
+// that context send id in the X-Request-ID header.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored by ContextWithRequestID.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithRequestIDGenerator sets the function generating the X-Request-ID of
+// requests whose context carries none. By default a random 128-bit hex ID is
+// generated; nil sends no header for such requests.
+func WithRequestIDGenerator(generate func() string) Option {
+	return func(c *Client) {
+		c.requestIDGenerator = generate
+		c.noRequestID = generate == nil
+	}
+}
+
+func (c Client) requestID(ctx context.Context) string {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return id
+	}
+
+	if c.noRequestID {
+		return ""
+	}
+
+	if c.requestIDGenerator != nil {
+		return c.requestIDGenerator()
+	}
+
+	return newRequestID()
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID_FromContext(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var ids []string
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get(headerRequestID))
+
+		w.Header().Set(headerRequestID, "server-"+r.Header.Get(headerRequestID))
+		_, err := w.Write([]byte(`{"ID":1,"Name":"Default Org"}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+
+	req, err := client.NewRequest(ctx, http.MethodGet, "/portal-api/organisations/1", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "req-1", req.Header.Get(headerRequestID))
+
+	resp, err := client.performRequest(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "server-req-1", resp.RequestID)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+
+	require.Len(t, ids, 2)
+	assert.Equal(t, "req-1", ids[0])
+	assert.Len(t, ids[1], 32, "a request ID is generated when the context has none")
+}
+
+func TestRequestID_Generator(t *testing.T) {
+	client, err := New(
+		WithToken("TOKEN"),
+		WithRequestIDGenerator(func() string { return "generated" }),
+	)
+	require.NoError(t, err)
+
+	req, err := client.NewRequest(context.Background(), http.MethodGet, "/portal-api/users", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "generated", req.Header.Get(headerRequestID))
+
+	req, err = client.NewRequest(context.Background(), http.MethodGet, "/portal-api/users", nil, nil, WithRequestIDGenerator(nil))
+	require.NoError(t, err)
+	assert.Empty(t, req.Header.Get(headerRequestID))
+}
+
+func TestRequestID_InAPIError(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRequestID, "portal-42")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, err := w.Write([]byte(`{"errors":["invalid organisation"],"status":"error"}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.Error(t, err)
+
+	var apiErr APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "portal-42", apiErr.RequestID)
+	assert.Contains(t, err.Error(), "invalid organisation (request id portal-42)")
+}
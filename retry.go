@@ -0,0 +1,392 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerRetryAfter       = "Retry-After"
+	defaultMaxRetries      = 3
+	defaultMinRetryBackoff = 100 * time.Millisecond
+	defaultMaxRetryBackoff = 5 * time.Second
+)
+
+// RetryPolicy decides whether a failed attempt should be retried and how long
+// the client should wait before sending the next one. attempt starts at zero
+// for the first request. Exactly one of resp and err is non-nil.
+type RetryPolicy interface {
+	Retry(attempt int, resp *http.Response, err error) (time.Duration, bool)
+}
+
+// ExponentialBackoff retries network errors and 429, 502, 503 and 504
+// responses. It honours the Retry-After header when the server sends one and
+// otherwise waits a jittered, exponentially growing delay between Min and Max.
+type ExponentialBackoff struct {
+	Min time.Duration
+	Max time.Duration
+	// MaxRetryAfter caps the wait a Retry-After header can ask for. Zero
+	// caps it at Max.
+	MaxRetryAfter time.Duration
+}
+
+func (b ExponentialBackoff) Retry(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if !shouldRetry(resp, err) {
+		return 0, false
+	}
+
+	if d, ok := retryAfter(resp); ok {
+		return b.capRetryAfter(d), true
+	}
+
+	return b.backoff(attempt), true
+}
+
+func (b ExponentialBackoff) capRetryAfter(d time.Duration) time.Duration {
+	return min(d, b.retryAfterLimit())
+}
+
+func (b ExponentialBackoff) retryAfterLimit() time.Duration {
+	if b.MaxRetryAfter > 0 {
+		return b.MaxRetryAfter
+	}
+
+	return max(b.Max, b.Min)
+}
+
+func (b ExponentialBackoff) backoff(attempt int) time.Duration {
+	if b.Min <= 0 {
+		return 0
+	}
+
+	maxBackoff := b.Max
+	if maxBackoff < b.Min {
+		maxBackoff = b.Min
+	}
+
+	d := maxBackoff
+	if attempt < 32 {
+		if next := b.Min << attempt; next > 0 && next < maxBackoff {
+			d = next
+		}
+	}
+
+	half := d / 2
+
+	//nolint:gosec
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		var certErr *tls.CertificateVerificationError
+
+		return !errors.As(err, &certErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter parses the Retry-After header, which holds either a number of
+// seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(resp.Header.Get(headerRetryAfter))
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	d := time.Until(at)
+	if d < 0 {
+		d = 0
+	}
+
+	return d, true
+}
+
+// doWithRetry sends req until it succeeds, the retry policy gives up or the
+// retry budget is spent. It also returns the number of attempts made.
+func (c Client) doWithRetry(ctx context.Context, httpClient HTTPClient, req *http.Request) (*http.Response, int, error) {
+	policy := c.policy()
+
+	refreshed := false
+
+	base, endpoint := c.endpoint(ctx, req)
+	failovers := 0
+
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := attemptRequest(ctx, req, attempt, base, endpoint)
+		if err != nil {
+			return nil, attempt, err
+		}
+
+		resp, err := c.roundTrip(ctx, httpClient, attemptReq, attempt, endpoint)
+
+		if retryReq, ok := c.retryUnauthorized(ctx, req, resp, err, &refreshed); ok {
+			req = retryReq
+			continue
+		}
+
+		if next, ok := c.failOver(ctx, endpoint, failovers, err); ok {
+			endpoint = next
+			failovers++
+
+			continue
+		}
+
+		if cause, ok := heldBack(err); ok {
+			return nil, attempt, cause
+		}
+
+		if attempt-failovers >= c.maxRetries || ctx.Err() != nil {
+			return resp, attempt + 1, err
+		}
+
+		wait, retry := policy.Retry(attempt, resp, err)
+		if !retry {
+			return resp, attempt + 1, err
+		}
+
+		if resp != nil {
+			drainBody(resp.Body)
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, attempt + 1, err
+		}
+	}
+}
+
+// roundTrip sends one attempt once the endpoint's breaker and the rate and
+// concurrency limits let it through, and records its outcome with them.
+// Attempts that are held back fail with a notSentError.
+func (c Client) roundTrip(
+	ctx context.Context,
+	httpClient HTTPClient,
+	req *http.Request,
+	attempt int,
+	endpoint string,
+) (*http.Response, error) {
+	var ticket breakerTicket
+
+	breaker := c.breaker(endpoint)
+	if breaker != nil {
+		var err error
+		if ticket, err = breaker.allow(); err != nil {
+			return nil, notSentError{err}
+		}
+	}
+
+	release, err := c.acquire(ctx)
+	if err != nil {
+		if breaker != nil {
+			breaker.cancel(ticket)
+		}
+
+		return nil, notSentError{err}
+	}
+
+	req, span := c.startAttemptSpan(ctx, req, attempt)
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	holdUntilClosed(resp, err, release)
+	c.logAttempt(ctx, req, resp, err, attempt, time.Since(start))
+	endSpan(span, resp, err)
+
+	if c.rateLimiter != nil {
+		c.rateLimiter.observe(resp, c.maxRetryAfter())
+	}
+
+	switch {
+	case breaker == nil:
+	case err != nil && ctx.Err() != nil:
+		// The caller gave up, which says nothing about the portal's health.
+		breaker.cancel(ticket)
+	default:
+		breaker.done(ticket, breakerFailure(resp, err))
+	}
+
+	return resp, err
+}
+
+// notSentError wraps the error of an attempt that was held back before
+// reaching the transport. Such attempts are not retried.
+type notSentError struct {
+	err error
+}
+
+func (e notSentError) Error() string {
+	return e.err.Error()
+}
+
+func (e notSentError) Unwrap() error {
+	return e.err
+}
+
+// heldBack reports whether err is from an attempt that never reached the
+// transport and returns the underlying error.
+func heldBack(err error) (error, bool) {
+	var notSent notSentError
+	if !errors.As(err, &notSent) {
+		return nil, false
+	}
+
+	return notSent.err, true
+}
+
+// retryUnauthorized returns the request to retry with a refreshed token
+// after the first 401 response of a call.
+func (c Client) retryUnauthorized(
+	ctx context.Context,
+	req *http.Request,
+	resp *http.Response,
+	err error,
+	refreshed *bool,
+) (*http.Request, bool) {
+	if *refreshed || err != nil || resp.StatusCode != http.StatusUnauthorized || ctx.Err() != nil {
+		return nil, false
+	}
+
+	*refreshed = true
+
+	retryReq, ok := c.reauthorize(ctx, req)
+	if ok {
+		drainBody(resp.Body)
+	}
+
+	return retryReq, ok
+}
+
+// attemptRequest returns the request to send for the given attempt, routed
+// to endpoint.
+func attemptRequest(ctx context.Context, req *http.Request, attempt int, base, endpoint string) (*http.Request, error) {
+	attemptReq, err := rewindRequest(ctx, req, attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	return route(ctx, attemptReq, base, endpoint)
+}
+
+// policy returns the configured retry policy or the default backoff.
+func (c Client) policy() RetryPolicy {
+	if c.retryPolicy != nil {
+		return c.retryPolicy
+	}
+
+	return c.defaultPolicy()
+}
+
+func (c Client) defaultPolicy() ExponentialBackoff {
+	return ExponentialBackoff{
+		Min: c.minRetryBackoff,
+		Max: defaultMaxRetryBackoff,
+	}
+}
+
+// maxRetryAfter returns the longest Retry-After wait the client honours:
+// the cap of its ExponentialBackoff policy, or of the default policy when
+// another one is set.
+func (c Client) maxRetryAfter() time.Duration {
+	b, ok := c.policy().(ExponentialBackoff)
+	if !ok {
+		b = c.defaultPolicy()
+	}
+
+	return b.retryAfterLimit()
+}
+
+// rewindRequest returns the request to send for the given attempt. Retries
+// get a clone with a fresh copy of the body so the payload is sent again.
+func rewindRequest(ctx context.Context, req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be rewound for retry")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	newReq := req.Clone(ctx)
+	newReq.Body = body
+
+	return newReq, nil
+}
+
+// rewindableBody returns a reader for which http.NewRequest can set GetBody,
+// buffering the body in memory when needed.
+func rewindableBody(body io.Reader) (io.Reader, error) {
+	switch body.(type) {
+	case *bytes.Buffer, *bytes.Reader, *strings.Reader:
+		return body, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+func drainBody(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 4096))
+	body.Close()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
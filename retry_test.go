@@ -0,0 +1,187 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry_ResendsBody(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var calls int32
+
+	srv.mux.HandleFunc("/portal-api/organisations", func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, "POST", r)
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"Name":"Retry Org"}`, string(body))
+
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		_, err = w.Write([]byte(`{"ID":1,"Name":"Retry Org"}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithRetryPolicy(ExponentialBackoff{Min: time.Millisecond, Max: 5 * time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	resp, err := client.Orgs().CreateOrg(context.Background(), &CreateOrgInput{Name: "Retry Org"})
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assertOrg(t, &Org{ID: 1, Name: "Retry Org"}, resp.Data)
+}
+
+func TestRetry_MaxRetries(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var calls int32
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetry_NoRetryOnClientError(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var calls int32
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetry_ContextCancelledDuringBackoff(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRetryAfter, "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.Orgs().GetOrg(ctx, 1)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRetryAfter(t *testing.T) {
+	tt := map[string]struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		"seconds": {
+			value: "2",
+			want:  2 * time.Second,
+			ok:    true,
+		},
+		"date in the past": {
+			value: "Wed, 02 Aug 2023 10:08:09 GMT",
+			want:  0,
+			ok:    true,
+		},
+		"missing": {
+			ok: false,
+		},
+		"invalid": {
+			value: "soon",
+			ok:    false,
+		},
+	}
+
+	for k, v := range tt {
+		t.Run(k, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if v.value != "" {
+				resp.Header.Set(headerRetryAfter, v.value)
+			}
+
+			got, ok := retryAfter(resp)
+			assert.Equal(t, v.ok, ok)
+			assert.Equal(t, v.want, got)
+		})
+	}
+}
+
+func TestExponentialBackoff_Bounds(t *testing.T) {
+	b := ExponentialBackoff{Min: 10 * time.Millisecond, Max: 80 * time.Millisecond}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		d := b.backoff(attempt)
+		assert.GreaterOrEqual(t, d, 5*time.Millisecond)
+		assert.LessOrEqual(t, d, 80*time.Millisecond)
+	}
+}
+
+func TestExponentialBackoff_CapsRetryAfter(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set(headerRetryAfter, "3600")
+
+	wait, retry := ExponentialBackoff{Min: 10 * time.Millisecond, Max: time.Second}.Retry(0, resp, nil)
+	assert.True(t, retry)
+	assert.Equal(t, time.Second, wait)
+
+	wait, _ = ExponentialBackoff{Max: time.Second, MaxRetryAfter: time.Minute}.Retry(0, resp, nil)
+	assert.Equal(t, time.Minute, wait)
+
+	resp.Header.Set(headerRetryAfter, "2")
+
+	wait, _ = ExponentialBackoff{Max: time.Second, MaxRetryAfter: time.Minute}.Retry(0, resp, nil)
+	assert.Equal(t, 2*time.Second, wait)
+}
@@ -0,0 +1,299 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrResponseTooLarge is matched by errors.Is for responses whose body
+// exceeds the limit set with WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ResponseTooLargeError is returned when a response body exceeds the limit
+// set with WithMaxResponseSize.
+type ResponseTooLargeError struct {
+	Method string
+	Path   string
+	Limit  int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("%v: %v %v exceeds %d bytes", ErrResponseTooLarge, e.Method, e.Path, e.Limit)
+}
+
+func (e *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+// WithMaxResponseSize fails requests whose response body is larger than n
+// bytes with a *ResponseTooLargeError. Zero, the default, means no limit.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseSize = n
+	}
+}
+
+// WithItemCallback makes list operations over items of type T pass each item
+// to fn as soon as it is decoded instead of collecting them, so the Data of
+// the output is empty. T is the item type of the list, for example User for
+// ListUsers; lists of other types are not affected. Returning an error from
+// fn stops the listing and returns that error.
+//
+// It is usually passed per call:
+//
+//	_, err := client.Users().ListUsers(ctx, nil, portal.WithItemCallback(func(u portal.User) error {
+//		return process(u)
+//	}))
+func WithItemCallback[T any](fn func(item T) error) Option {
+	return func(c *Client) {
+		callbacks := make(map[reflect.Type]interface{}, len(c.itemCallbacks)+1)
+		for t, cb := range c.itemCallbacks {
+			callbacks[t] = cb
+		}
+
+		callbacks[reflect.TypeOf((*T)(nil)).Elem()] = fn
+		c.itemCallbacks = callbacks
+	}
+}
+
+// withoutItemCallbacks drops the item callbacks, for internal lookups that
+// need the listed items.
+func withoutItemCallbacks() Option {
+	return func(c *Client) {
+		c.itemCallbacks = nil
+	}
+}
+
+// unpaginated returns the query that makes the portal return every item of
+// a list in one response.
+func unpaginated() url.Values {
+	return url.Values{"p": []string{"-2"}}
+}
+
+// limitedReader fails with a *ResponseTooLargeError once more than limit
+// bytes have been read.
+type limitedReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+	req   *http.Request
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n > l.limit {
+		return 0, l.tooLarge()
+	}
+
+	// Read one byte past the limit to tell a body of exactly limit bytes
+	// from a larger one, but never hand that byte to the caller.
+	if remaining := l.limit - l.n + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+
+	if l.n > l.limit {
+		return n - 1, l.tooLarge()
+	}
+
+	return n, err
+}
+
+func (l *limitedReader) tooLarge() error {
+	return &ResponseTooLargeError{
+		Method: l.req.Method,
+		Path:   l.req.URL.Path,
+		Limit:  l.limit,
+	}
+}
+
+// limitBody applies the maximum response size to the body of resp.
+func (c Client) limitBody(req *http.Request, resp *http.Response) (io.Reader, error) {
+	if c.maxResponseSize <= 0 {
+		return resp.Body, nil
+	}
+
+	l := &limitedReader{r: resp.Body, limit: c.maxResponseSize, req: req}
+	if resp.ContentLength > c.maxResponseSize {
+		return nil, l.tooLarge()
+	}
+
+	return l, nil
+}
+
+type streamDecoderKey struct{}
+
+type streamDecoder func(r io.Reader) error
+
+// streamable reports the decoder to use for resp when its body can be
+// decoded without buffering it. Cached and coalesced requests need the whole
+// body and are always buffered.
+func (c Client) streamable(req *http.Request, resp *http.Response) (streamDecoder, bool) {
+	if !c.streams(req) {
+		return nil, false
+	}
+
+	decode := req.Context().Value(streamDecoderKey{}).(streamDecoder)
+
+	return decode, resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices
+}
+
+// streams reports whether a successful response to req is decoded while it
+// is read.
+func (c Client) streams(req *http.Request) bool {
+	_, ok := req.Context().Value(streamDecoderKey{}).(streamDecoder)
+
+	return ok && c.cache == nil && c.flights == nil
+}
+
+// errAwaitingHeaders is returned when a streamed request gets no response
+// headers within the read timeout.
+var errAwaitingHeaders error = headerTimeoutError{}
+
+type headerTimeoutError struct{}
+
+func (headerTimeoutError) Error() string   { return "read timeout awaiting response headers" }
+func (headerTimeoutError) Timeout() bool   { return true }
+func (headerTimeoutError) Temporary() bool { return true }
+
+// streamingClient sends streamed requests without the total timeout of the
+// embedded client, which would also cut off slow item callbacks. For them
+// the timeout only covers waiting for the response headers, and reading the
+// body is bounded by the request context.
+type streamingClient struct {
+	*http.Client
+	streams func(req *http.Request) bool
+}
+
+func (s streamingClient) Do(req *http.Request) (*http.Response, error) {
+	if s.Timeout <= 0 || !s.streams(req) {
+		return s.Client.Do(req)
+	}
+
+	client := *s.Client
+	client.Timeout = 0
+
+	ctx, cancel := context.WithCancelCause(req.Context())
+	timer := time.AfterFunc(s.Timeout, func() { cancel(errAwaitingHeaders) })
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if !timer.Stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		return nil, &url.Error{Op: urlErrorOp(req.Method), URL: req.URL.String(), Err: errAwaitingHeaders}
+	}
+
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: func() { cancel(nil) }}
+
+	return resp, nil
+}
+
+// cancelOnClose releases the context of a streamed request with its body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// urlErrorOp returns the Op net/http uses in its *url.Error for method.
+func urlErrorOp(method string) string {
+	if method == "" {
+		return "Get"
+	}
+
+	return method[:1] + strings.ToLower(method[1:])
+}
+
+// listItems GETs path and decodes the JSON array it returns straight from
+// the response stream, passing the items to the item callback when one is
+// set. The response is returned for its metadata.
+func listItems[T any](ctx context.Context, c *Client, path string, params url.Values, opts ...Option) ([]T, *APIResponse, error) {
+	var items []T
+
+	onItem, _ := c.copy(opts...).itemCallbacks[reflect.TypeOf((*T)(nil)).Elem()].(func(T) error)
+
+	decode := streamDecoder(func(r io.Reader) error {
+		return decodeArray(r, func(item T) error {
+			if onItem != nil {
+				return onItem(item)
+			}
+
+			items = append(items, item)
+
+			return nil
+		})
+	})
+
+	resp, err := c.doGet(context.WithValue(ctx, streamDecoderKey{}, decode), path, params, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !resp.streamed {
+		if err := decode(bytes.NewReader(resp.Body)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return items, resp, nil
+}
+
+// decodeArray decodes a JSON array from r one element at a time. A null
+// array has no elements.
+func decodeArray[T any](r io.Reader, fn func(item T) error) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok == nil {
+		return nil
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected a JSON array, got %v", tok)
+	}
+
+	for dec.More() {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return err
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	_, err = dec.Token()
+
+	return err
+}
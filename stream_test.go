@@ -0,0 +1,217 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUsersServer(t *testing.T, n int) *server {
+	srv := NewServer(t)
+
+	srv.mux.HandleFunc("/portal-api/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerETag, `"users"`)
+
+		var b strings.Builder
+
+		b.WriteString("[")
+
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				b.WriteString(",")
+			}
+
+			fmt.Fprintf(&b, `{"Email":"user%d@example.com"}`, i)
+		}
+
+		b.WriteString("]\n")
+
+		// Flush before writing so the body is sent chunked, without a
+		// Content-Length.
+		w.(http.Flusher).Flush()
+
+		_, err := w.Write([]byte(b.String()))
+		assert.NoError(t, err)
+	})
+
+	return srv
+}
+
+func TestStream_ListUsers(t *testing.T) {
+	srv := newUsersServer(t, 500)
+	defer srv.Close()
+
+	for name, opts := range map[string][]Option{
+		"streamed": nil,
+		"buffered": {WithCache(NewMemoryCache(0))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, err := New(append([]Option{WithBaseURL(srv.srv.URL), WithToken("TOKEN")}, opts...)...)
+			require.NoError(t, err)
+
+			out, err := client.Users().ListUsers(context.Background(), nil)
+			require.NoError(t, err)
+			require.Len(t, out.Users, 500)
+			assert.Equal(t, "user499@example.com", out.Users[499].Email)
+		})
+	}
+}
+
+func TestStream_MaxResponseSize(t *testing.T) {
+	srv := newUsersServer(t, 500)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"ID":1,"Name":"` + strings.Repeat("x", 1024) + `"}`))
+		assert.NoError(t, err)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxResponseSize(512),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Users().ListUsers(context.Background(), nil)
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+
+	var tooLarge *ResponseTooLargeError
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, int64(512), tooLarge.Limit)
+	assert.Equal(t, "/portal-api/users", tooLarge.Path)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrResponseTooLarge, "Content-Length is checked up front")
+
+	_, err = client.Users().ListUsers(context.Background(), nil, WithMaxResponseSize(0))
+	assert.NoError(t, err)
+}
+
+func TestStream_ItemCallback(t *testing.T) {
+	srv := newUsersServer(t, 10)
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	var emails []string
+
+	out, err := client.Users().ListUsers(context.Background(), nil, WithItemCallback(func(u User) error {
+		emails = append(emails, u.Email)
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Empty(t, out.Users)
+	assert.Len(t, emails, 10)
+	assert.Equal(t, "user0@example.com", emails[0])
+
+	errStop := errors.New("stop")
+	seen := 0
+
+	_, err = client.Users().ListUsers(context.Background(), nil, WithItemCallback(func(u User) error {
+		seen++
+		if seen == 3 {
+			return errStop
+		}
+
+		return nil
+	}))
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 3, seen)
+
+	out, err = client.Users().ListUsers(context.Background(), nil, WithItemCallback(func(p Product) error {
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Len(t, out.Users, 10, "callbacks for other item types are ignored")
+
+	var products int
+
+	client, err = New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithItemCallback(func(p Product) error {
+			products++
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	out, err = client.Users().ListUsers(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Len(t, out.Users, 10, "a client-level callback does not break other lists")
+	assert.Zero(t, products)
+}
+
+func TestStream_ReadTimeoutCoversOnlyHeaders(t *testing.T) {
+	srv := newUsersServer(t, 1000)
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithMaxRetries(0),
+		WithReadTimeout(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	seen := 0
+
+	_, err = client.Users().ListUsers(context.Background(), nil, WithItemCallback(func(u User) error {
+		if seen == 0 {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		seen++
+
+		return nil
+	}))
+	require.NoError(t, err, "slow callbacks outlast the read timeout")
+	assert.Equal(t, 1000, seen)
+
+	slow := NewServer(t)
+	defer slow.Close()
+
+	slow.mux.HandleFunc("/portal-api/users", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	_, err = client.Users().ListUsers(context.Background(), nil, WithBaseURL(slow.srv.URL))
+
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+}
+
+func TestDecodeArray(t *testing.T) {
+	var items []int
+
+	collect := func(i int) error {
+		items = append(items, i)
+		return nil
+	}
+
+	require.NoError(t, decodeArray(strings.NewReader(`null`), collect))
+	assert.Empty(t, items)
+
+	require.NoError(t, decodeArray(strings.NewReader(` [1, 2, 3] `), collect))
+	assert.Equal(t, []int{1, 2, 3}, items)
+
+	assert.Error(t, decodeArray(strings.NewReader(`{"ID":1}`), collect))
+	assert.Error(t, decodeArray(strings.NewReader(`[1, 2`), collect))
+}
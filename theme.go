@@ -23,28 +23,37 @@ type themes struct {
 }
 
 func (t themes) UploadTheme(ctx context.Context, input io.Reader, opts ...Option) (*UploadThemeOutput, error) {
+	ctx = withOperation(ctx, "Themes.UploadTheme")
+
+	if err := t.client.checkCapability(ctx, CapabilityThemes, opts...); err != nil {
+		return nil, err
+	}
+
 	form, contentType, err := createThemeForm(input)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = t.client.doPost(
+	resp, err := t.client.doPost(
 		ctx,
 		pathThemesUpload,
 		form,
 		nil,
-		WithHeaders(
+		append(opts, WithHeaders(
 			map[string]string{
 				"Content-Type": contentType,
 			},
-		),
+		))...,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &UploadThemeOutput{}, nil
+	return &UploadThemeOutput{
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
+	}, nil
 }
 
 func createThemeForm(r io.Reader) (io.Reader, string, error) {
@@ -65,7 +74,10 @@ func createThemeForm(r io.Reader) (io.Reader, string, error) {
 	return buf, formWriter.FormDataContentType(), nil
 }
 
-type UploadThemeOutput struct{}
+type UploadThemeOutput struct {
+	DryRun bool
+	Meta   Meta
+}
 
 type Theme struct {
 	Author  string `json:"Author,omitempty"`
@@ -78,10 +90,12 @@ type Theme struct {
 
 type ThemeOutput struct {
 	Data *Theme
+	Meta Meta
 }
 
 type ListThemesOutput struct {
 	Data []Theme
+	Meta Meta
 }
 
 type Err struct {
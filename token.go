@@ -0,0 +1,231 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource supplies the token sent in the Authorization header. It is
+// asked for a token for every request, so implementations should be cheap and
+// must be safe for concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenRefresher is implemented by token sources that cache their token.
+// When the portal answers 401, the client calls RefreshToken to bypass the
+// cache and retries the request once if the token changed. Sources that do
+// not implement it are asked for a token again with Token.
+type TokenRefresher interface {
+	RefreshToken(ctx context.Context) (string, error)
+}
+
+// WithTokenSource sets the source of the Authorization token.
+func WithTokenSource(src TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = src
+	}
+}
+
+// StaticToken returns a source that always returns token.
+func StaticToken(token string) TokenSource {
+	return staticToken(token)
+}
+
+type staticToken string
+
+func (t staticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// EnvToken returns a source that reads the token from the environment
+// variable name on every request.
+func EnvToken(name string) TokenSource {
+	return envToken(name)
+}
+
+type envToken string
+
+func (e envToken) Token(context.Context) (string, error) {
+	token := strings.TrimSpace(os.Getenv(string(e)))
+	if token == "" {
+		return "", fmt.Errorf("environment variable %v is not set", string(e))
+	}
+
+	return token, nil
+}
+
+// FileToken returns a source that reads the token from the file at path. The
+// file is read again whenever its modification time or size changes, so a
+// rotated token is picked up without restarting.
+func FileToken(path string) TokenSource {
+	return &fileToken{path: path}
+}
+
+type fileToken struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+func (f *fileToken) Token(context.Context) (string, error) {
+	return f.read(false)
+}
+
+func (f *fileToken) RefreshToken(context.Context) (string, error) {
+	return f.read(true)
+}
+
+func (f *fileToken) read(force bool) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("reading token file: %w", err)
+	}
+
+	if !force && f.token != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("reading token file: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %v is empty", f.path)
+	}
+
+	f.token = token
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+
+	return token, nil
+}
+
+// CommandToken returns a source that runs the command name with args and
+// uses its trimmed standard output as the token. The output is cached until
+// the portal rejects it.
+func CommandToken(name string, args ...string) TokenSource {
+	return &commandToken{name: name, args: args}
+}
+
+type commandToken struct {
+	name string
+	args []string
+
+	mu    sync.Mutex
+	token string
+}
+
+func (c *commandToken) Token(ctx context.Context) (string, error) {
+	return c.run(ctx, false)
+}
+
+func (c *commandToken) RefreshToken(ctx context.Context) (string, error) {
+	return c.run(ctx, true)
+}
+
+func (c *commandToken) run(ctx context.Context, force bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !force && c.token != "" {
+		return c.token, nil
+	}
+
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, c.name, c.args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("running token command: %w: %v", err, msg)
+		}
+
+		return "", fmt.Errorf("running token command: %w", err)
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", errors.New("token command returned an empty token")
+	}
+
+	c.token = token
+
+	return token, nil
+}
+
+func (c Client) token(ctx context.Context) (string, error) {
+	if c.tokenSource == nil {
+		return "", errors.New("token is required")
+	}
+
+	token, err := c.tokenSource.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("fetching token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (c Client) refreshToken(ctx context.Context) (string, error) {
+	refresher, ok := c.tokenSource.(TokenRefresher)
+	if !ok {
+		return c.token(ctx)
+	}
+
+	token, err := refresher.RefreshToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("refreshing token: %w", err)
+	}
+
+	return token, nil
+}
+
+// reauthorize fetches a fresh token after a 401 and returns a copy of req
+// carrying it. It reports false when the token could not be refreshed or did
+// not change, as sending the request again would fail the same way.
+func (c Client) reauthorize(ctx context.Context, req *http.Request) (*http.Request, bool) {
+	if c.tokenSource == nil {
+		return nil, false
+	}
+
+	token, err := c.refreshToken(ctx)
+	if err != nil {
+		if c.logger != nil {
+			c.logger.WarnContext(ctx, "portal token refresh failed", slog.String("error", err.Error()))
+		}
+
+		return nil, false
+	}
+
+	if token == req.Header.Get(headerAuthorization) {
+		return nil, false
+	}
+
+	newReq := req.Clone(ctx)
+	newReq.Header.Set(headerAuthorization, token)
+
+	return newReq, true
+}
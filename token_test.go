@@ -0,0 +1,157 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenSource_Static(t *testing.T) {
+	token, err := StaticToken("TOKEN").Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN", token)
+}
+
+func TestTokenSource_Env(t *testing.T) {
+	t.Setenv("PORTAL_TEST_TOKEN", " TOKEN\n")
+
+	src := EnvToken("PORTAL_TEST_TOKEN")
+
+	token, err := src.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN", token)
+
+	t.Setenv("PORTAL_TEST_TOKEN", "")
+
+	_, err = src.Token(context.Background())
+	assert.ErrorContains(t, err, "PORTAL_TEST_TOKEN is not set")
+}
+
+func TestTokenSource_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("FIRST\n"), 0o600))
+
+	src := FileToken(path)
+
+	token, err := src.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "FIRST", token)
+
+	require.NoError(t, os.WriteFile(path, []byte("SECOND-TOKEN\n"), 0o600))
+
+	token, err = src.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "SECOND-TOKEN", token)
+
+	require.NoError(t, os.Remove(path))
+
+	_, err = src.Token(context.Background())
+	assert.ErrorContains(t, err, "reading token file")
+}
+
+func TestTokenSource_Command(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	script := `echo x >> "$1"; echo "TOKEN-$(wc -l < "$1" | tr -d ' ')"`
+
+	src := CommandToken("sh", "-c", script, "sh", counter)
+
+	token, err := src.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN-1", token)
+
+	token, err = src.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN-1", token, "token is cached")
+
+	token, err = src.(TokenRefresher).RefreshToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN-2", token)
+
+	_, err = CommandToken("sh", "-c", "echo denied >&2; exit 1").Token(context.Background())
+	assert.ErrorContains(t, err, "denied")
+}
+
+func TestTokenSource_RefreshOn401(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var requests int32
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if r.Header.Get(headerAuthorization) != "NEW" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, err := w.Write([]byte(`{"ID":1,"Name":"Default Org"}`))
+		assert.NoError(t, err)
+	})
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("OLD"), 0o600))
+
+	src := FileToken(path)
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithTokenSource(src),
+		WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+
+	// Rotate the token behind the cached value without changing the size or
+	// modification time, so only the refresh after the 401 picks it up.
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	_, err = src.Token(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("NEW"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), info.ModTime()))
+
+	org, err := client.Orgs().GetOrg(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Default Org", org.Data.Name)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestTokenSource_NoRetryWhenTokenUnchanged(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var requests int32
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestTokenSource_Error(t *testing.T) {
+	client, err := New(WithTokenSource(EnvToken("PORTAL_TEST_MISSING_TOKEN")))
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	assert.ErrorContains(t, err, "fetching token")
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	tracerName           = "github.com/TykTechnologies/portal-go"
+	defaultOperationName = "Portal.Request"
+	attrOperation        = "portal.operation"
+	attrResourceType     = "portal.resource.type"
+	attrResourceID       = "portal.resource.id"
+	attrResourceIDs      = "portal.resource.ids"
+	attrAttempt          = "portal.attempt"
+	attrHTTPMethod       = "http.request.method"
+	attrHTTPStatusCode   = "http.response.status_code"
+	attrURLPath          = "url.path"
+	attrServerAddress    = "server.address"
+	attrErrorType        = "error.type"
+)
+
+var operationVerbs = []string{
+	"Create", "Get", "List", "Update", "Delete",
+	"Approve", "Reject", "Provision", "Sync", "Upload",
+}
+
+// WithTracerProvider enables OpenTelemetry tracing. Every operation gets a
+// span named after it, for example "Apps.ProvisionApp", with a client span
+// for each HTTP attempt. Trace context is injected into outgoing requests
+// with the global propagator.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracerProvider = tp
+	}
+}
+
+func (c Client) tracer() trace.Tracer {
+	if c.tracerProvider == nil {
+		return noop.NewTracerProvider().Tracer(tracerName)
+	}
+
+	return c.tracerProvider.Tracer(tracerName)
+}
+
+// startOperationSpan starts the span covering a whole operation, retries
+// included.
+func (c Client) startOperationSpan(ctx context.Context, req *http.Request) (context.Context, trace.Span) {
+	op := operationFromContext(ctx)
+
+	name := op.name
+	if name == "" {
+		name = defaultOperationName
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String(attrOperation, name),
+		attribute.String(attrHTTPMethod, req.Method),
+		attribute.String(attrURLPath, req.URL.Path),
+	}
+
+	if resourceType := op.resourceType(); resourceType != "" {
+		attrs = append(attrs, attribute.String(attrResourceType, resourceType))
+	}
+
+	if len(op.ids) > 0 {
+		attrs = append(attrs, attribute.Int64(attrResourceID, op.ids[len(op.ids)-1]))
+	}
+
+	if len(op.ids) > 1 {
+		attrs = append(attrs, attribute.Int64Slice(attrResourceIDs, op.ids))
+	}
+
+	return c.tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// startAttemptSpan starts the client span for a single HTTP attempt and
+// injects its context into the request headers.
+func (c Client) startAttemptSpan(ctx context.Context, req *http.Request, attempt int) (*http.Request, trace.Span) {
+	ctx, span := c.tracer().Start(
+		ctx,
+		"HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String(attrHTTPMethod, req.Method),
+			attribute.String(attrURLPath, req.URL.Path),
+			attribute.String(attrServerAddress, req.URL.Host),
+			attribute.Int(attrAttempt, attempt+1),
+		),
+	)
+
+	if c.tracerProvider == nil {
+		return req, span
+	}
+
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return req, span
+}
+
+// endSpan records the outcome of a request on span and ends it.
+func endSpan(span trace.Span, resp *http.Response, err error) {
+	if resp != nil {
+		span.SetAttributes(attribute.Int(attrHTTPStatusCode, resp.StatusCode))
+
+		if resp.StatusCode >= http.StatusBadRequest && err == nil {
+			span.SetAttributes(attribute.String(attrErrorType, resp.Status))
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// resourceType derives the resource from the method part of the operation
+// name, so "Orgs.CreateTeam" yields "team" and "ARs.ListARs" yields "ar".
+func (o operation) resourceType() string {
+	_, method, ok := strings.Cut(o.name, ".")
+	if !ok {
+		return ""
+	}
+
+	for _, verb := range operationVerbs {
+		if rest, found := strings.CutPrefix(method, verb); found && rest != "" {
+			if verb == "List" || verb == "Sync" {
+				rest = strings.TrimSuffix(rest, "s")
+			}
+
+			return strings.ToLower(rest)
+		}
+	}
+
+	return strings.ToLower(method)
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing_OperationAndAttemptSpans(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	srv := NewServer(t)
+	defer srv.Close()
+
+	var calls int32
+
+	srv.mux.HandleFunc("/portal-api/access_requests/5/approve", func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get("Traceparent"))
+
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, err := w.Write([]byte(`{"status":"ok"}`))
+		assert.NoError(t, err)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithTracerProvider(tp),
+		WithRetryPolicy(ExponentialBackoff{Min: time.Millisecond, Max: time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	_, err = client.ARs().ApproveAR(context.Background(), 5)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	op := spans[2]
+	assert.Equal(t, "ARs.ApproveAR", op.Name())
+	assertSpanAttr(t, op.Attributes(), attrResourceType, attribute.StringValue("ar"))
+	assertSpanAttr(t, op.Attributes(), attrResourceID, attribute.Int64Value(5))
+	assertSpanAttr(t, op.Attributes(), attrHTTPStatusCode, attribute.IntValue(http.StatusOK))
+
+	for i, attempt := range spans[:2] {
+		assert.Equal(t, trace.SpanKindClient, attempt.SpanKind())
+		assert.Equal(t, op.SpanContext().SpanID(), attempt.Parent().SpanID())
+		assertSpanAttr(t, attempt.Attributes(), attrAttempt, attribute.IntValue(i+1))
+	}
+
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assertSpanAttr(t, spans[0].Attributes(), attrHTTPStatusCode, attribute.IntValue(http.StatusServiceUnavailable))
+}
+
+func TestOperation_ResourceType(t *testing.T) {
+	tt := map[string]string{
+		"Orgs.CreateTeam":         "team",
+		"Orgs.ListOrgs":           "org",
+		"ARs.ListARs":             "ar",
+		"Apps.ProvisionApp":       "app",
+		"Providers.SyncProviders": "provider",
+		"":                        "",
+	}
+
+	for name, want := range tt {
+		assert.Equal(t, want, operation{name: name}.resourceType(), name)
+	}
+}
+
+func assertSpanAttr(t *testing.T, attrs []attribute.KeyValue, key string, want attribute.Value) {
+	for _, attr := range attrs {
+		if string(attr.Key) == key {
+			assert.Equal(t, want, attr.Value, key)
+			return
+		}
+	}
+
+	t.Errorf("attribute %v not found", key)
+}
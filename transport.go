@@ -0,0 +1,152 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultMaxIdleConns          = 100
+	defaultMaxIdleConnsPerHost   = 10
+	defaultIdleConnTimeout       = 90 * time.Second
+	defaultKeepAlive             = 30 * time.Second
+	defaultTLSHandshakeTimeout   = 10 * time.Second
+	defaultResponseHeaderTimeout = 0
+)
+
+// WithMaxIdleConns sets the size of the idle connection pool shared by all
+// hosts and the number of idle connections kept per host.
+func WithMaxIdleConns(total, perHost int) Option {
+	return func(c *Client) {
+		c.maxIdleConns = total
+		c.maxIdleConnsPerHost = perHost
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection stays in the pool.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.idleConnTimeout = d
+	}
+}
+
+// WithKeepAlive sets the TCP keep-alive period. A negative value disables
+// keep-alive probes.
+func WithKeepAlive(d time.Duration) Option {
+	return func(c *Client) {
+		c.keepAlive = d
+	}
+}
+
+// WithHTTP2 enables or disables HTTP/2 negotiation over TLS.
+func WithHTTP2(enabled bool) Option {
+	return func(c *Client) {
+		c.disableHTTP2 = !enabled
+	}
+}
+
+func WithTLSHandshakeTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.tlsHandshakeTimeout = d
+	}
+}
+
+// WithResponseHeaderTimeout limits how long to wait for the response headers
+// once the request has been written. Zero means no limit.
+func WithResponseHeaderTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.responseHeaderTimeout = d
+	}
+}
+
+// Close releases the idle connections held by the client's transport. The
+// client can still be used afterwards; new connections are opened on demand.
+func (c *Client) Close() error {
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
+	}
+
+	return nil
+}
+
+// newTransport builds the transport owned by the client for its whole
+// lifetime. Transport settings are fixed when the client is created; per-call
+// options can only override the connect and read timeouts.
+func (c Client) newTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   c.connectTimeout,
+		KeepAlive: c.keepAlive,
+	}
+
+	dial := c.dial
+	if dial == nil {
+		dial = dialer.DialContext
+	}
+
+	transport := &http.Transport{
+		Proxy:                 c.proxy,
+		TLSClientConfig:       c.tlsConfig(),
+		DialContext:           dialContext(dial, c.connectTimeout),
+		ForceAttemptHTTP2:     !c.disableHTTP2,
+		MaxIdleConns:          c.maxIdleConns,
+		MaxIdleConnsPerHost:   c.maxIdleConnsPerHost,
+		IdleConnTimeout:       c.idleConnTimeout,
+		TLSHandshakeTimeout:   c.tlsHandshakeTimeout,
+		ResponseHeaderTimeout: c.responseHeaderTimeout,
+	}
+
+	if c.disableHTTP2 {
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport
+}
+
+// defaultHTTPClient wraps the shared transport with the read timeout of the
+// current call.
+func (c Client) defaultHTTPClient() streamingClient {
+	transport := c.transport
+	if transport == nil {
+		transport = c.newTransport()
+	}
+
+	return streamingClient{
+		Client: &http.Client{
+			Transport: transport,
+			Timeout:   c.readTimeout,
+		},
+		streams: c.streams,
+	}
+}
+
+type dialTimeoutKey struct{}
+
+func withDialTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, dialTimeoutKey{}, d)
+}
+
+// dialContext dials with the connect timeout stored on the request context,
+// falling back to the client's connect timeout.
+func dialContext(dial DialFunc, timeout time.Duration) DialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		d := timeout
+		if v, ok := ctx.Value(dialTimeoutKey{}).(time.Duration); ok {
+			d = v
+		}
+
+		if d > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+
+		return dial(ctx, network, addr)
+	}
+}
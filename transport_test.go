@@ -0,0 +1,93 @@
+// Copyright 2023 Tyk Technologies
+// SPDX-License-Identifier: MPL-2.0
+
+package portal
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_ReusesConnections(t *testing.T) {
+	var conns int32
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"ID":1,"Name":"Default Org"}`))
+		assert.NoError(t, err)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	client, err := New(
+		WithBaseURL(srv.URL),
+		WithToken("TOKEN"),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err := client.Orgs().GetOrg(context.Background(), 1)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns))
+}
+
+func TestTransport_ReadTimeout(t *testing.T) {
+	srv := NewServer(t)
+	defer srv.Close()
+
+	srv.mux.HandleFunc("/portal-api/organisations/1", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	client, err := New(
+		WithBaseURL(srv.srv.URL),
+		WithToken("TOKEN"),
+		WithReadTimeout(20*time.Millisecond),
+		WithMaxRetries(0),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Orgs().GetOrg(context.Background(), 1)
+	assert.Error(t, err)
+}
+
+func TestTransport_Options(t *testing.T) {
+	client, err := New(
+		WithToken("TOKEN"),
+		WithConnectTimeout(5*time.Second),
+		WithMaxIdleConns(20, 4),
+		WithIdleConnTimeout(time.Minute),
+		WithHTTP2(false),
+		WithTLSHandshakeTimeout(3*time.Second),
+		WithResponseHeaderTimeout(7*time.Second),
+	)
+	require.NoError(t, err)
+
+	transport := client.transport
+	require.NotNil(t, transport)
+
+	assert.Equal(t, 20, transport.MaxIdleConns)
+	assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.False(t, transport.ForceAttemptHTTP2)
+	assert.NotNil(t, transport.TLSNextProto)
+	assert.Equal(t, 3*time.Second, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 7*time.Second, transport.ResponseHeaderTimeout)
+	assert.Equal(t, defaultReadTimeout, client.defaultHTTPClient().Timeout)
+	assert.NoError(t, client.Close())
+}
@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 )
 
 const (
@@ -31,18 +30,18 @@ type users struct {
 }
 
 func (p users) CreateUser(ctx context.Context, input *CreateUserInput, opts ...Option) (*CreateUserOutput, error) {
+	ctx = withOperation(ctx, "Users.CreateUser")
+
 	payload, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 
-	if !p.client.skipValidation {
-		if err := input.validate(); err != nil {
-			return nil, err
-		}
+	if err := p.client.validateInput(input, opts...); err != nil {
+		return nil, err
 	}
 
-	resp, err := p.client.doPost(ctx, pathUsers, bytes.NewReader(payload), nil)
+	resp, err := p.client.doCreate(ctx, pathUsers, payload, p.findUserByEmail(input), opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -54,12 +53,16 @@ func (p users) CreateUser(ctx context.Context, input *CreateUserInput, opts ...O
 	}
 
 	return &CreateUserOutput{
-		Data: &user,
+		Data:   &user,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p users) GetUser(ctx context.Context, id int64, opts ...Option) (*GetUserOutput, error) {
-	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathUser, id), nil)
+	ctx = withOperation(ctx, "Users.GetUser", id)
+
+	resp, err := p.client.doGet(ctx, fmt.Sprintf(pathUser, id), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -71,27 +74,27 @@ func (p users) GetUser(ctx context.Context, id int64, opts ...Option) (*GetUserO
 
 	return &GetUserOutput{
 		Data: &user,
+		Meta: resp.Meta(),
 	}, nil
 }
 
 func (p users) ListUsers(ctx context.Context, options *ListUsersInput, opts ...Option) (*ListUsersOutput, error) {
-	resp, err := p.client.doGet(ctx, pathUsers, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var users []User
+	ctx = withOperation(ctx, "Users.ListUsers")
 
-	if err := resp.Unmarshal(&users); err != nil {
+	users, resp, err := listItems[User](ctx, p.client, pathUsers, nil, opts...)
+	if err != nil {
 		return nil, err
 	}
 
 	return &ListUsersOutput{
 		Users: users,
+		Meta:  resp.Meta(),
 	}, nil
 }
 
 func (p users) UpdateUser(ctx context.Context, id int64, input *UpdateUserInput, opts ...Option) (*UpdateUserOutput, error) {
+	ctx = withOperation(ctx, "Users.UpdateUser", id)
+
 	input.ID = nil
 
 	payload, err := json.Marshal(input)
@@ -99,9 +102,7 @@ func (p users) UpdateUser(ctx context.Context, id int64, input *UpdateUserInput,
 		return nil, err
 	}
 
-	log.Println(string(payload))
-
-	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathUser, id), bytes.NewReader(payload), nil)
+	resp, err := p.client.doPut(ctx, fmt.Sprintf(pathUser, id), bytes.NewReader(payload), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -113,17 +114,24 @@ func (p users) UpdateUser(ctx context.Context, id int64, input *UpdateUserInput,
 	}
 
 	return &UpdateUserOutput{
-		Data: &user,
+		Data:   &user,
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
 	}, nil
 }
 
 func (p users) DeleteUser(ctx context.Context, id int64, opts ...Option) (*DeleteUserOutput, error) {
-	_, err := p.client.doDelete(ctx, fmt.Sprintf(pathUser, id), nil, nil)
+	ctx = withOperation(ctx, "Users.DeleteUser", id)
+
+	resp, err := p.client.doDelete(ctx, fmt.Sprintf(pathUser, id), nil, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &GetUserOutput{}, nil
+	return &GetUserOutput{
+		DryRun: resp.DryRun,
+		Meta:   resp.Meta(),
+	}, nil
 }
 
 type UserInput struct {
@@ -158,6 +166,7 @@ type ListUsersInput struct{}
 
 type ListUsersOutput struct {
 	Users []User
+	Meta  Meta
 }
 
 type User struct {
@@ -179,7 +188,9 @@ type User struct {
 }
 
 type UserOutput struct {
-	Data *User
+	Data   *User
+	DryRun bool
+	Meta   Meta
 }
 
 type UpdateUserOutput = UserOutput